@@ -0,0 +1,105 @@
+package pager
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// buffer is one of the documents paged through, along with where the user
+// left it.
+type buffer struct {
+	name string
+	doc  document
+
+	// Highlighted lines, or the highlighter coloring the lines as they're
+	// shown when the document is too large to be highlighted up front.
+	styled      []string
+	highlighter *highlighter
+
+	search search
+
+	// The first line shown, and the first of its rows when it's wrapped.
+	top, topRow int
+
+	// The line selected, and where the selection started or -1.
+	cursor, anchor int
+
+	// Positions marked with a letter.
+	marks map[rune]position
+
+	// The first column shown when lines aren't wrapped.
+	left int
+
+	// Markdown source, rendered again when the width changes.
+	markdown      string
+	markdownWidth int
+	headings      []heading
+}
+
+// newBuffer splits a document into lines.
+func newBuffer(name, content string) buffer {
+	return buffer{name: name, doc: newMemory(strings.Split(content, "\n"))}
+}
+
+// expandTabs replaces tabs so that lines are as wide as they're displayed.
+func expandTabs(lines []string) []string {
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(line, "\t", "    ")
+	}
+	return lines
+}
+
+// openBuffers indexes every file into its own buffer. A single argument which
+// isn't an existing path is shown as is, like `gum pager "hello world"`.
+func openBuffers(args []string, strike overstrike) ([]buffer, error) {
+	if len(args) == 1 {
+		if _, err := os.Stat(args[0]); err != nil {
+			return []buffer{newBuffer("", args[0])}, nil
+		}
+	}
+
+	buffers := make([]buffer, 0, len(args))
+	for _, name := range args {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", name, err)
+		}
+		doc, err := indexFile(f, strike)
+		if err != nil {
+			return nil, err
+		}
+		buffers = append(buffers, buffer{name: name, doc: doc})
+	}
+	return buffers, nil
+}
+
+// buf returns the buffer being shown.
+func (m *model) buf() *buffer {
+	return &m.buffers[m.current]
+}
+
+// switchBuffer shows the buffer delta buffers away, where it was left.
+func (m *model) switchBuffer(delta int) {
+	if len(m.buffers) < 2 {
+		return
+	}
+	m.current = (m.current + delta + len(m.buffers)) % len(m.buffers)
+	m.refresh()
+}
+
+// headerView shows the name of the current file when paging through several.
+func (m *model) headerView() string {
+	if len(m.buffers) < 2 {
+		return ""
+	}
+	position := fmt.Sprintf(" (%d/%d)", m.current+1, len(m.buffers))
+	name := m.buf().name
+	if w := m.width - lipgloss.Width(position); w > 0 && lipgloss.Width(name) > w {
+		name = ansi.TruncateLeft(name, lipgloss.Width(name)-w+1, "…")
+	}
+	return m.headerStyle.Render(name) + m.lineNumberStyle.Render(position)
+}
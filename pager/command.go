@@ -1,14 +1,17 @@
 package pager
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"os"
+	"strings"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/gum/v2/internal/stdin"
 	"charm.land/gum/v2/internal/timeout"
+	"github.com/charmbracelet/x/term"
 )
 
 // Run provides a shell script interface for the viewport bubble.
@@ -17,43 +20,163 @@ func (o Options) Run() error {
 	vp := viewport.New(viewport.WithWidth(o.Style.Width), viewport.WithHeight(o.Style.Height))
 	vp.Style = o.Style.ToLipgloss()
 
-	if o.Content == "" {
+	// Check the language and theme before any input is read.
+	if _, err := o.highlighter("", nil); err != nil {
+		return err
+	}
+	if o.Markdown {
+		if _, err := markdownRenderer(o.Theme, 0); err != nil {
+			return err
+		}
+	}
+
+	// Input from a pipe or a followed file is shown as it's read.
+	var input *stream
+	strike := overstrike{
+		bold:      o.OverstrikeBoldStyle.ToLipgloss(),
+		underline: o.OverstrikeUnderlineStyle.ToLipgloss(),
+	}
+	var buffers []buffer
+	switch {
+	case o.Follow != "" && len(o.Content) > 0:
+		return fmt.Errorf("--follow can't be combined with other content")
+	case o.Follow != "" && o.Markdown:
+		return fmt.Errorf("--follow can't be combined with --markdown")
+	case o.Follow != "":
+		f, err := os.Open(o.Follow)
+		if err != nil {
+			return fmt.Errorf("unable to follow file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck
+		input = newStream(f, true, strike)
+		buffers = []buffer{{name: o.Follow, doc: newMemory(nil)}}
+	case len(o.Content) == 0:
+		if stdin.IsEmpty() {
+			return fmt.Errorf("provide some content to display")
+		}
+		// Markdown is rendered as a whole, so it can't be streamed.
+		info, err := os.Stdin.Stat()
+		switch {
+		case err == nil && info.Mode()&os.ModeNamedPipe != 0 && !o.Markdown:
+			input = newStream(os.Stdin, false, strike)
+			buffers = []buffer{{doc: newMemory(nil)}}
+		case err == nil && info.Mode().IsRegular():
+			doc, err := indexFile(os.Stdin, strike)
+			if err != nil {
+				return err
+			}
+			buffers = []buffer{{doc: doc}}
+		}
+		if buffers != nil {
+			break
+		}
 		stdin, err := stdin.Read()
 		if err != nil {
 			return fmt.Errorf("unable to read stdin")
 		}
-		if stdin != "" {
-			// Sanitize the input from stdin by removing backspace sequences.
-			backspace := regexp.MustCompile(".\x08")
-			o.Content = backspace.ReplaceAllString(stdin, "")
-		} else {
-			return fmt.Errorf("provide some content to display")
+		buffers = []buffer{newBuffer("", strike.render(stdin))}
+	default:
+		var err error
+		buffers, err = openBuffers(o.Content, strike)
+		if err != nil {
+			return err
 		}
 	}
 
 	m := model{
 		viewport:            vp,
 		help:                help.New(),
-		content:             o.Content,
-		origContent:         o.Content,
+		buffers:             buffers,
+		headerStyle:         o.HeaderStyle.ToLipgloss(),
+		theme:               o.Theme,
 		showLineNumbers:     o.ShowLineNumbers,
 		lineNumberStyle:     o.LineNumberStyle.ToLipgloss(),
 		softWrap:            o.SoftWrap,
+		horizontalStep:      max(o.HorizontalStep, 1),
 		matchStyle:          o.MatchStyle.ToLipgloss(),
 		matchHighlightStyle: o.MatchHighlightStyle.ToLipgloss(),
 		keymap:              defaultKeymap(),
+		gotoInput:           newGotoInput(),
+		selecting:           o.Select,
+		selectOnly:          o.Select,
+		selectionStyle:      o.SelectionStyle.ToLipgloss(),
+		input:               input,
+		follow:              input != nil,
 	}
+	defer func() {
+		for _, b := range buffers {
+			if d, ok := b.doc.(*indexed); ok {
+				d.file.Close() //nolint:errcheck,gosec
+			}
+		}
+	}()
+	for i := range buffers {
+		b := &buffers[i]
+		b.search = search{literal: o.SearchLiteral, smartCase: o.SmartCase}
+		b.anchor = -1
+		if input != nil {
+			continue
+		}
+		if o.Markdown {
+			b.markdown = strings.Join(readAll(b.doc), "\n")
+			continue
+		}
+
+		// Large documents are only sampled to detect their language, and
+		// highlighted line by line as they're shown.
+		sample := make([]string, min(b.doc.Len(), detectLines))
+		for i := range sample {
+			sample[i] = b.doc.Line(i)
+		}
+		h, err := o.highlighter(b.name, sample)
+		switch {
+		case err != nil:
+			return err
+		case h == nil:
+		case large(b.doc):
+			b.highlighter = h
+		default:
+			b.styled = h.highlight(readAll(b.doc))
+		}
+	}
+	if input != nil {
+		// Streams are detected from their name, or their first lines.
+		m.detect = func(lines []string) (*highlighter, error) {
+			return o.highlighter(o.Follow, lines)
+		}
+	}
+	m.keymap.Left.SetEnabled(!o.SoftWrap)
+	m.keymap.Right.SetEnabled(!o.SoftWrap)
+	m.keymap.NextFile.SetEnabled(len(buffers) > 1)
+	m.keymap.PrevFile.SetEnabled(len(buffers) > 1)
 
 	ctx, cancel := timeout.Context(o.Timeout)
 	defer cancel()
 
-	_, err := tea.NewProgram(
+	// Selected lines are printed to stdout, so the pager is shown on stderr
+	// when that's what it's for, or when stdout is redirected.
+	output := os.Stdout
+	if o.Select || !term.IsTerminal(os.Stdout.Fd()) {
+		output = os.Stderr
+	}
+	tm, err := tea.NewProgram(
 		m,
+		tea.WithOutput(output),
 		tea.WithContext(ctx),
 	).Run()
 	if err != nil {
 		return fmt.Errorf("unable to start program: %w", err)
 	}
 
+	m = tm.(model)
+	if m.err != nil {
+		return m.err
+	}
+	if o.Select && m.output == nil {
+		return errors.New("no lines selected")
+	}
+	for _, line := range m.output {
+		fmt.Println(line)
+	}
 	return nil
 }
@@ -0,0 +1,181 @@
+package pager
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// largeFile is the size above which files are no longer highlighted as a
+// whole nor searched as the search is typed.
+const largeFile = 8 << 20
+
+// maxCachedLines is how many lines read from a file are kept around.
+const maxCachedLines = 4096
+
+// document gives access to the lines of a buffer.
+type document interface {
+	// Len returns the number of lines.
+	Len() int
+	// Line returns a line as it's displayed.
+	Line(i int) string
+	// Plain returns a line without its styling, as it's searched.
+	Plain(i int) string
+	// Scan calls fn with the plain text of every line starting at from.
+	Scan(from int, fn func(i int, plain string))
+}
+
+// memory is a document held in memory.
+type memory struct {
+	text  []string
+	plain []string
+}
+
+func newMemory(text []string) *memory {
+	return &memory{text: expandTabs(text)}
+}
+
+func (d *memory) Len() int           { return len(d.text) }
+func (d *memory) Line(i int) string  { return d.text[i] }
+func (d *memory) Plain(i int) string { d.index(i); return d.plain[i] }
+
+func (d *memory) Scan(from int, fn func(i int, plain string)) {
+	for i := from; i < d.Len(); i++ {
+		fn(i, d.Plain(i))
+	}
+}
+
+// index strips the styling of the lines up to i, once.
+func (d *memory) index(i int) {
+	for j := len(d.plain); j <= i; j++ {
+		d.plain = append(d.plain, ansi.Strip(d.text[j]))
+	}
+}
+
+// append adds lines at the end of the document.
+func (d *memory) append(text ...string) {
+	d.text = append(d.text, expandTabs(text)...)
+}
+
+// indexed is a document read from a file when its lines are needed, through
+// the offsets where each line starts.
+type indexed struct {
+	file    *os.File
+	size    int64
+	offsets []int64
+	cache   map[int]string
+	strike  overstrike
+}
+
+// indexFile finds where the lines of a file start. The file is kept open to
+// read the lines later on.
+func indexFile(f *os.File, strike overstrike) (*indexed, error) {
+	offsets := []int64{0}
+	buf := make([]byte, 4<<20)
+	var pos int64
+	for {
+		n, err := f.Read(buf)
+		chunk := buf[:n]
+		for {
+			i := bytes.IndexByte(chunk, '\n')
+			if i < 0 {
+				break
+			}
+			pos += int64(i + 1)
+			offsets = append(offsets, pos)
+			chunk = chunk[i+1:]
+		}
+		pos += int64(len(chunk))
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s: %w", f.Name(), err)
+		}
+	}
+	// The last line doesn't always end with a newline.
+	if pos > offsets[len(offsets)-1] {
+		offsets = append(offsets, pos)
+	}
+	return &indexed{
+		file:    f,
+		size:    pos,
+		offsets: offsets,
+		cache:   map[int]string{},
+		strike:  strike,
+	}, nil
+}
+
+func (d *indexed) Len() int { return len(d.offsets) - 1 }
+
+func (d *indexed) Line(i int) string {
+	if line, ok := d.cache[i]; ok {
+		return line
+	}
+	buf := make([]byte, d.offsets[i+1]-d.offsets[i])
+	n, _ := d.file.ReadAt(buf, d.offsets[i])
+	line := d.clean(strings.TrimSuffix(string(buf[:n]), "\n"))
+
+	if len(d.cache) >= maxCachedLines {
+		clear(d.cache)
+	}
+	d.cache[i] = line
+	return line
+}
+
+func (d *indexed) Plain(i int) string { return plain(d.Line(i)) }
+
+// Scan reads the lines one after the other, rather than one at a time.
+func (d *indexed) Scan(from int, fn func(i int, plain string)) {
+	if from >= d.Len() {
+		return
+	}
+	start := d.offsets[from]
+	scanner := bufio.NewScanner(io.NewSectionReader(d.file, start, d.size-start))
+	scanner.Buffer(make([]byte, 1<<20), int(d.size-start)+1)
+	for i := from; i < d.Len() && scanner.Scan(); i++ {
+		fn(i, plain(d.clean(scanner.Text())))
+	}
+}
+
+// clean removes the line ending of a line read from a file, renders its
+// overstruck text and replaces its tabs.
+func (d *indexed) clean(line string) string {
+	line = d.strike.render(strings.TrimSuffix(line, "\r"))
+	if strings.IndexByte(line, '\t') >= 0 {
+		line = strings.ReplaceAll(line, "\t", "    ")
+	}
+	return line
+}
+
+// plain strips the styling of a line, when it has any.
+func plain(line string) string {
+	if strings.IndexByte(line, ansi.ESC) < 0 {
+		return line
+	}
+	return ansi.Strip(line)
+}
+
+// large reports whether a document is too big to be read as a whole.
+func large(doc document) bool {
+	d, ok := doc.(*indexed)
+	return ok && d.size > largeFile
+}
+
+// readAll returns all the lines of a document.
+func readAll(doc document) []string {
+	if l, ok := doc.(*memory); ok {
+		return l.text
+	}
+	all := make([]string, doc.Len())
+	for i := range all {
+		all[i] = doc.Line(i)
+	}
+	return all
+}
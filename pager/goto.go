@@ -0,0 +1,150 @@
+package pager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// position is a place in a document, as kept by a mark.
+type position struct {
+	line, row int
+}
+
+// newGotoInput returns the input where a line or percentage to go to is typed.
+func newGotoInput() textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "line or percentage"
+	input.Prompt = ":"
+	styles := input.Styles()
+	styles.Focused.Prompt = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	styles.Blurred.Prompt = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	input.SetStyles(styles)
+	return input
+}
+
+// updateGoto handles the keys typed in the goto input.
+func (m model) updateGoto(msg tea.KeyPressMsg) (model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keymap.ConfirmSearch):
+		m.gotoInput.Blur()
+		m.jump(m.gotoInput.Value())
+		return m, nil
+	case key.Matches(msg, m.keymap.CancelSearch):
+		m.gotoInput.Blur()
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	return m, cmd
+}
+
+// jump goes to a line number, or a percentage of the document when it ends
+// with %.
+func (m *model) jump(value string) {
+	value = strings.TrimSpace(value)
+	percent := strings.HasSuffix(value, "%")
+	n, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+	switch {
+	case value == "":
+	case err != nil || n < 0:
+		m.message = fmt.Sprintf("invalid line %q", value)
+	case percent:
+		m.gotoPercent(n)
+	default:
+		m.gotoLine(n)
+	}
+}
+
+// gotoLine shows a line, counting from 1, at the top of the view.
+func (m *model) gotoLine(n int) {
+	m.scrollTo(n-1, 0)
+}
+
+// gotoPercent shows the line a percentage of the way through the document.
+func (m *model) gotoPercent(n int) {
+	m.scrollTo(min(n, 100)*m.buf().doc.Len()/100, 0)
+}
+
+// countKey handles the digits typed before a key, like 123G or 50%, and the
+// letter following m or '. It reports whether the key was used.
+func (m *model) countKey(msg tea.KeyPressMsg) bool {
+	k := msg.String()
+	count, pending := m.count, m.pending
+	m.count, m.pending = "", ""
+
+	if pending != "" {
+		r := []rune(k)
+		if len(r) != 1 || !unicode.IsLetter(r[0]) {
+			return key.Matches(msg, m.keymap.Quit)
+		}
+		if pending == "m" {
+			m.setMark(r[0])
+		} else {
+			m.gotoMark(r[0])
+		}
+		return true
+	}
+
+	n, _ := strconv.Atoi(count)
+	switch {
+	case len(k) == 1 && k[0] >= '0' && k[0] <= '9' && (count != "" || k != "0"):
+		m.count = count + k
+	case key.Matches(msg, m.keymap.SetMark, m.keymap.GotoMark):
+		m.pending = k
+	case count == "":
+		return false
+	case key.Matches(msg, m.keymap.Home, m.keymap.End):
+		m.gotoLine(n)
+	case k == "%":
+		m.gotoPercent(n)
+	default:
+		// Escape drops the count rather than quitting.
+		return key.Matches(msg, m.keymap.Quit)
+	}
+	return true
+}
+
+// setMark remembers the position in the current buffer.
+func (m *model) setMark(r rune) {
+	b := m.buf()
+	if b.marks == nil {
+		b.marks = map[rune]position{}
+	}
+	b.marks[r] = position{line: b.top, row: b.topRow}
+	m.message = fmt.Sprintf("mark %c set", r)
+}
+
+// gotoMark goes back to a position remembered in the current buffer.
+func (m *model) gotoMark(r rune) {
+	p, ok := m.buf().marks[r]
+	if !ok {
+		m.message = fmt.Sprintf("mark %c not set", r)
+		return
+	}
+	m.scrollTo(p.line, p.row)
+}
+
+// positionView shows the first line in view, the number of lines and how far
+// through the document the view goes.
+func (m *model) positionView() string {
+	b := m.buf()
+	total := b.doc.Len()
+	if total == 0 {
+		return ""
+	}
+	last, rows := b.top, -b.topRow
+	for ; last < total-1; last++ {
+		rows += m.rowsOf(last)
+		if rows >= m.visibleRows() {
+			break
+		}
+	}
+	return fmt.Sprintf("%d/%d %d%%", min(b.top+1, total), total, (last+1)*100/total)
+}
@@ -0,0 +1,79 @@
+package pager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// detectLines is how many lines are looked at to detect the language of a
+// document.
+const detectLines = 200
+
+// highlighter colors lines of code with chroma.
+type highlighter struct {
+	lexer chroma.Lexer
+	style *chroma.Style
+}
+
+// highlighter returns the highlighter for a document, using the language
+// given, or the one detected from the file name or the content. It returns nil
+// when the document is plain text.
+func (o Options) highlighter(name string, lines []string) (*highlighter, error) {
+	style, ok := styles.Registry[strings.ToLower(o.SyntaxTheme)]
+	if !ok {
+		return nil, fmt.Errorf("unknown syntax theme %q", o.SyntaxTheme)
+	}
+
+	var lexer chroma.Lexer
+	switch {
+	case o.Language != "":
+		lexer = lexers.Get(o.Language)
+		if lexer == nil {
+			return nil, fmt.Errorf("unknown language %q", o.Language)
+		}
+	case name != "":
+		lexer = lexers.Match(name)
+	}
+	if lexer == nil && len(lines) > 0 {
+		lexer = lexers.Analyse(strings.Join(lines, "\n"))
+	}
+	if lexer == nil || lexer == lexers.Fallback || lexer.Config().Name == "plaintext" {
+		return nil, nil
+	}
+	return &highlighter{lexer: chroma.Coalesce(lexer), style: style}, nil
+}
+
+// highlight returns the lines colored, one for each line given. The lines are
+// returned as is when they can't be tokenised.
+func (h *highlighter) highlight(lines []string) []string {
+	if h == nil || len(lines) == 0 {
+		return lines
+	}
+	it, err := h.lexer.Tokenise(nil, strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return lines
+	}
+
+	tokens := chroma.SplitTokensIntoLines(it.Tokens())
+	if len(tokens) != len(lines) {
+		return lines
+	}
+	styled := make([]string, len(lines))
+	var b strings.Builder
+	for i, line := range tokens {
+		for j := range line {
+			line[j].Value = strings.TrimSuffix(line[j].Value, "\n")
+		}
+		b.Reset()
+		if err := formatters.TTY256.Format(&b, h.style, chroma.Literator(line...)); err != nil {
+			return lines
+		}
+		styled[i] = b.String()
+	}
+	return styled
+}
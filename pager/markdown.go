@@ -0,0 +1,151 @@
+package pager
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/glamour/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// heading is a section of a Markdown document, found on a rendered line.
+type heading struct {
+	level int
+	title string
+	line  int
+}
+
+var (
+	atxHeading = regexp.MustCompile(`^ {0,3}(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	fence      = regexp.MustCompile("^ {0,3}(```|~~~)")
+	inlineCode = strings.NewReplacer("`", "", "**", "", "__", "")
+)
+
+// markdownRenderer returns a glamour renderer wrapping text at width.
+func markdownRenderer(theme string, width int) (*glamour.TermRenderer, error) {
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStylePath(theme),
+		glamour.WithWordWrap(width),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render markdown: %w", err)
+	}
+	return r, nil
+}
+
+// renderMarkdown renders the Markdown source of the current buffer to fit the
+// given width, unless it already does. It reports whether it did.
+func (m *model) renderMarkdown(width int) bool {
+	b := m.buf()
+	if b.markdown == "" || width == b.markdownWidth || width <= 0 {
+		return false
+	}
+	b.markdownWidth = width
+
+	var out string
+	r, err := markdownRenderer(m.theme, width)
+	if err == nil {
+		out, err = r.Render(b.markdown)
+	}
+	if err != nil {
+		out = b.markdown
+	}
+	doc := newMemory(strings.Split(strings.Trim(out, "\n"), "\n"))
+	b.doc = doc
+	b.headings = findHeadings(b.markdown, doc.text)
+
+	// Matches moved along with the text.
+	b.search.matches = nil
+	b.search.find(doc, 0)
+	b.search.current = min(b.search.current, len(b.search.matches)-1)
+	return true
+}
+
+// findHeadings lists the headings of a Markdown source, along with the
+// rendered line each one ended up on. Headings which can't be found in the
+// rendered lines are left out.
+func findHeadings(source string, lines []string) []heading {
+	var headings []heading
+	inFence := false
+	next := 0
+	for _, line := range strings.Split(source, "\n") {
+		if fence.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		match := atxHeading.FindStringSubmatch(line)
+		if inFence || match == nil {
+			continue
+		}
+		title := inlineCode.Replace(match[2])
+		for i := next; i < len(lines); i++ {
+			if strings.Contains(ansi.Strip(lines[i]), title) {
+				headings = append(headings, heading{level: len(match[1]), title: title, line: i})
+				next = i + 1
+				break
+			}
+		}
+	}
+	return headings
+}
+
+// openOutline shows the outline with the section being read selected.
+func (m *model) openOutline() {
+	m.outline = true
+	m.outlineCursor = 0
+	for i, h := range m.buf().headings {
+		if h.line <= m.buf().top {
+			m.outlineCursor = i
+		}
+	}
+}
+
+// updateOutline handles the keys while the outline is open.
+func (m model) updateOutline(msg tea.KeyPressMsg) (model, tea.Cmd) {
+	km := m.keymap
+	vkm := m.viewport.KeyMap
+	switch {
+	case key.Matches(msg, km.Abort):
+		return m, tea.Interrupt
+	case key.Matches(msg, km.Outline, km.Quit):
+		m.outline = false
+	case key.Matches(msg, vkm.Up):
+		m.outlineCursor = max(m.outlineCursor-1, 0)
+	case key.Matches(msg, vkm.Down):
+		m.outlineCursor = min(m.outlineCursor+1, len(m.buf().headings)-1)
+	case key.Matches(msg, km.Jump):
+		m.outline = false
+		m.scrollTo(m.buf().headings[m.outlineCursor].line, 0)
+	}
+	return m, nil
+}
+
+// outlineView renders the headings, indented by level, around the cursor.
+func (m model) outlineView() string {
+	height := max(m.viewport.Height()-m.viewport.Style.GetVerticalFrameSize()-4, 1)
+	headings := m.buf().headings
+	start := max(0, min(m.outlineCursor-height/2, len(headings)-height))
+	end := min(start+height, len(headings))
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		h := headings[i]
+		line := ansi.Truncate(strings.Repeat("  ", h.level-1)+h.title, max(m.viewport.Width()-8, 1), "…")
+		if i == m.outlineCursor {
+			line = m.matchHighlightStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	km := m.keymap
+	b.WriteString("\n" + m.help.ShortHelpView([]key.Binding{km.Jump, km.Outline}))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(0, 1).
+		Render(b.String())
+}
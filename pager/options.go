@@ -9,14 +9,27 @@ import (
 // Options are the options for the pager.
 type Options struct {
 	//nolint:staticcheck
-	Style               style.Styles  `embed:"" help:"Style the pager" set:"defaultBorder=rounded" set:"defaultPadding=0 1" set:"defaultBorderForeground=212" envprefix:"GUM_PAGER_"`
-	Content             string        `arg:"" optional:"" help:"Display content to scroll"`
-	ShowLineNumbers     bool          `help:"Show line numbers" default:"true"`
-	LineNumberStyle     style.Styles  `embed:"" prefix:"line-number." help:"Style the line numbers" set:"defaultForeground=237" envprefix:"GUM_PAGER_LINE_NUMBER_"`
-	SoftWrap            bool          `help:"Soft wrap lines" default:"true" negatable:""`
-	MatchStyle          style.Styles  `embed:"" prefix:"match." help:"Style the matched text" set:"defaultForeground=212" set:"defaultBold=true" envprefix:"GUM_PAGER_MATCH_"`                                                      //nolint:staticcheck
-	MatchHighlightStyle style.Styles  `embed:"" prefix:"match-highlight." help:"Style the matched highlight text" set:"defaultForeground=235" set:"defaultBackground=225" set:"defaultBold=true" envprefix:"GUM_PAGER_MATCH_HIGH_"` //nolint:staticcheck
-	Timeout             time.Duration `help:"Timeout until command exits" default:"0s" env:"GUM_PAGER_TIMEOUT"`
+	Style                    style.Styles  `embed:"" help:"Style the pager" set:"defaultBorder=rounded" set:"defaultPadding=0 1" set:"defaultBorderForeground=212" envprefix:"GUM_PAGER_"`
+	Content                  []string      `arg:"" optional:"" help:"Display content to scroll, or files to page through"`
+	ShowLineNumbers          bool          `help:"Show line numbers" default:"true"`
+	LineNumberStyle          style.Styles  `embed:"" prefix:"line-number." help:"Style the line numbers" set:"defaultForeground=237" envprefix:"GUM_PAGER_LINE_NUMBER_"`
+	SoftWrap                 bool          `help:"Soft wrap lines" default:"true" negatable:""`
+	HorizontalStep           int           `help:"Columns to scroll left and right when lines aren't wrapped" default:"6" env:"GUM_PAGER_HORIZONTAL_STEP"`
+	MatchStyle               style.Styles  `embed:"" prefix:"match." help:"Style the matched text" set:"defaultForeground=212" set:"defaultBold=true" envprefix:"GUM_PAGER_MATCH_"`                                                      //nolint:staticcheck
+	MatchHighlightStyle      style.Styles  `embed:"" prefix:"match-highlight." help:"Style the matched highlight text" set:"defaultForeground=235" set:"defaultBackground=225" set:"defaultBold=true" envprefix:"GUM_PAGER_MATCH_HIGH_"` //nolint:staticcheck
+	SelectionStyle           style.Styles  `embed:"" prefix:"selection." help:"Style the selected lines" set:"defaultForeground=235" set:"defaultBackground=212" envprefix:"GUM_PAGER_SELECTION_"`                                       //nolint:staticcheck
+	OverstrikeBoldStyle      style.Styles  `embed:"" prefix:"overstrike.bold." help:"Style the bold text of man pages" set:"defaultBold=true" envprefix:"GUM_PAGER_OVERSTRIKE_BOLD_"`                                                    //nolint:staticcheck
+	OverstrikeUnderlineStyle style.Styles  `embed:"" prefix:"overstrike.underline." help:"Style the underlined text of man pages" set:"defaultUnderline=true" envprefix:"GUM_PAGER_OVERSTRIKE_UNDERLINE_"`                               //nolint:staticcheck
+	HeaderStyle              style.Styles  `embed:"" prefix:"header." help:"Style the file name header" set:"defaultForeground=212" set:"defaultBold=true" envprefix:"GUM_PAGER_HEADER_"`                                                //nolint:staticcheck
+	SearchLiteral            bool          `help:"Search for the text typed as is, instead of as a regular expression" env:"GUM_PAGER_SEARCH_LITERAL"`
+	SmartCase                bool          `help:"Ignore case when searching, unless the search has uppercase letters" default:"true" negatable:"" env:"GUM_PAGER_SMART_CASE"`
+	Language                 string        `help:"Language to highlight, detected from the file name or the content by default" short:"l" env:"GUM_PAGER_LANGUAGE"`
+	SyntaxTheme              string        `help:"Chroma style used to highlight code" default:"monokai" env:"GUM_PAGER_SYNTAX_THEME"`
+	Select                   bool          `help:"Select lines to print, starting with the line at the top" env:"GUM_PAGER_SELECT"`
+	Markdown                 bool          `help:"Render the content as Markdown" env:"GUM_PAGER_MARKDOWN"`
+	Theme                    string        `help:"Glamour theme to use for markdown rendering" default:"pink" env:"GUM_PAGER_THEME"`
+	Follow                   string        `help:"Follow a file as it grows, like tail -f" type:"path" placeholder:"<file>"`
+	Timeout                  time.Duration `help:"Timeout until command exits" default:"0s" env:"GUM_PAGER_TIMEOUT"`
 
 	// Deprecated: this has no effect anymore.
 	HelpStyle style.Styles `embed:"" prefix:"help." help:"Style the help text" set:"defaultForeground=241" envprefix:"GUM_PAGER_HELP_" hidden:""`
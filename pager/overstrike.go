@@ -0,0 +1,76 @@
+package pager
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+)
+
+// overstrike renders the text that man and nroff make bold by typing a
+// character over itself, X\bX, and underline by typing it over an
+// underscore, _\bX.
+type overstrike struct {
+	bold, underline lipgloss.Style
+}
+
+const (
+	struckBold = 1 << iota
+	struckUnderline
+)
+
+// render replaces the overstruck characters of a line with styled text.
+func (o overstrike) render(line string) string {
+	if strings.IndexByte(line, '\b') < 0 {
+		return line
+	}
+
+	var (
+		b     strings.Builder
+		run   []rune
+		style int
+	)
+	flush := func() {
+		switch style {
+		case struckBold:
+			b.WriteString(o.bold.Render(string(run)))
+		case struckUnderline:
+			b.WriteString(o.underline.Render(string(run)))
+		case struckBold | struckUnderline:
+			b.WriteString(o.bold.Render(o.underline.Render(string(run))))
+		default:
+			b.WriteString(string(run))
+		}
+		run = run[:0]
+	}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\b' {
+			continue
+		}
+		// A character can be struck several times, as in _\bX\bX.
+		struck := 0
+		for i+2 < len(runes) && runes[i+1] == '\b' {
+			next := runes[i+2]
+			switch {
+			case next == r:
+				struck |= struckBold
+			case r == '_':
+				struck |= struckUnderline
+			case next == '_':
+				struck |= struckUnderline
+				next = r
+			}
+			r = next
+			i += 2
+		}
+		if struck != style {
+			flush()
+			style = struck
+		}
+		run = append(run, r)
+	}
+	flush()
+	return b.String()
+}
@@ -13,15 +13,26 @@ import (
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
-	"github.com/charmbracelet/x/ansi"
 )
 
 type keymap struct {
 	Home,
 	End,
+	Left,
+	Right,
 	Search,
 	NextMatch,
 	PrevMatch,
+	NextFile,
+	PrevFile,
+	Outline,
+	Goto,
+	SetMark,
+	GotoMark,
+	Select,
+	PrintSelection,
+	CancelSelection,
+	Jump,
 	Abort,
 	Quit,
 	ConfirmSearch,
@@ -40,10 +51,19 @@ func (k keymap) ShortHelp() []key.Binding {
 			key.WithKeys("up", "down"),
 			key.WithHelp("↓↑", "navigate"),
 		),
+		k.Left,
+		k.PrintSelection,
+		k.CancelSelection,
 		k.Quit,
 		k.Search,
 		k.NextMatch,
 		k.PrevMatch,
+		k.NextFile,
+		k.PrevFile,
+		k.Outline,
+		k.Goto,
+		k.SetMark,
+		k.Select,
 	}
 }
 
@@ -57,6 +77,15 @@ func defaultKeymap() keymap {
 			key.WithKeys("G", "end"),
 			key.WithHelp("G", "end"),
 		),
+		Left: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←→", "scroll"),
+			key.WithDisabled(),
+		),
+		Right: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithDisabled(),
+		),
 		Search: key.NewBinding(
 			key.WithKeys("/"),
 			key.WithHelp("/", "search"),
@@ -69,6 +98,51 @@ func defaultKeymap() keymap {
 			key.WithKeys("n"),
 			key.WithHelp("n", "next match"),
 		),
+		NextFile: key.NewBinding(
+			key.WithKeys("]", "tab"),
+			key.WithHelp("]", "next file"),
+			key.WithDisabled(),
+		),
+		PrevFile: key.NewBinding(
+			key.WithKeys("[", "shift+tab"),
+			key.WithHelp("[", "previous file"),
+			key.WithDisabled(),
+		),
+		Outline: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "outline"),
+			key.WithDisabled(),
+		),
+		Goto: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "go to line"),
+		),
+		SetMark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark"),
+		),
+		GotoMark: key.NewBinding(
+			key.WithKeys("'"),
+			key.WithHelp("'", "go to mark"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "select"),
+		),
+		PrintSelection: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "print"),
+			key.WithDisabled(),
+		),
+		CancelSelection: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+			key.WithDisabled(),
+		),
+		Jump: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "jump"),
+		),
 		Abort: key.NewBinding(
 			key.WithKeys("ctrl+c"),
 			key.WithHelp("ctrl+c", "abort"),
@@ -89,146 +163,319 @@ func defaultKeymap() keymap {
 }
 
 type model struct {
-	content             string
-	origContent         string
 	viewport            viewport.Model
 	help                help.Model
 	showLineNumbers     bool
 	lineNumberStyle     lipgloss.Style
 	softWrap            bool
-	search              search
+	horizontalStep      int
 	matchStyle          lipgloss.Style
 	matchHighlightStyle lipgloss.Style
-	maxWidth            int
+	textWidth           int
 	keymap              keymap
+	width               int
+	height              int
+	err                 error
+
+	// Documents paged through, along with the one shown.
+	buffers     []buffer
+	current     int
+	headerStyle lipgloss.Style
+
+	// Streamed input, and whether the view sticks to its end.
+	input    *stream
+	follow   bool
+	newLines int
+
+	// Markdown rendering, and the outline of the headings.
+	theme         string
+	outline       bool
+	outlineCursor int
+
+	// Going to a line typed, or to the line or percentage counted before a
+	// key, along with marks waiting for their letter.
+	gotoInput textinput.Model
+	count     string
+	pending   string
+	message   string
+
+	// Lines selected, printed once the pager quits. When selecting is what the
+	// pager is for, the selection can't be left.
+	selecting      bool
+	selectOnly     bool
+	selectionStyle lipgloss.Style
+	output         []string
+
+	// Detects the language of streamed lines from the first ones.
+	detect func([]string) (*highlighter, error)
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (m model) Init() tea.Cmd {
+	if m.input != nil {
+		return m.input.wait()
+	}
+	return nil
+}
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.processText(msg)
+		m.width, m.height = msg.Width, msg.Height
+		m.refresh()
+		if m.follow {
+			m.gotoBottom()
+		}
+	case streamMsg:
+		return m.appendLines(msg)
 	case tea.KeyPressMsg:
-		return m.keyHandler(msg)
+		if m.outline {
+			return m.updateOutline(msg)
+		}
+		m.message = ""
+		m, cmd := m.keyHandler(msg)
+		// Scrolling away from the end pauses following, coming back resumes it.
+		m.follow = m.input != nil && m.atBottom()
+		if m.follow {
+			m.newLines = 0
+		}
+		m.updateKeymap()
+		return m, cmd
 	}
 
-	m.keymap.PrevMatch.SetEnabled(m.search.query != nil)
-	m.keymap.NextMatch.SetEnabled(m.search.query != nil)
+	m.updateKeymap()
 
-	var cmd tea.Cmd
-	m.search.input, cmd = m.search.input.Update(msg)
-	return m, cmd
+	var cmds [2]tea.Cmd
+	s := &m.buf().search
+	s.input, cmds[0] = s.input.Update(msg)
+	m.gotoInput, cmds[1] = m.gotoInput.Update(msg)
+	return m, tea.Batch(cmds[:]...)
+}
+
+// appendLines adds streamed lines to the content, scrolling along when
+// following.
+func (m model) appendLines(msg streamMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.err = msg.err
+		return m, tea.Quit
+	}
+	if len(msg.lines) > 0 {
+		b := m.buf()
+		doc := b.doc.(*memory)
+		from := doc.Len()
+		doc.append(msg.lines...)
+		added := doc.text[from:]
+		if m.detect != nil {
+			var err error
+			b.highlighter, err = m.detect(added)
+			if err != nil {
+				m.err = err
+				return m, tea.Quit
+			}
+			m.detect = nil
+		}
+		b.styled = append(b.styled, b.highlighter.highlight(added)...)
+		b.search.find(doc, from)
+		if m.follow {
+			m.gotoBottom()
+		} else {
+			m.newLines += len(msg.lines)
+		}
+	}
+	if msg.done {
+		return m, nil
+	}
+	return m, m.input.wait()
+}
+
+// updateKeymap enables the keys which make sense for the current buffer.
+func (m *model) updateKeymap() {
+	b := m.buf()
+	m.keymap.Outline.SetEnabled(len(b.headings) > 0)
+	m.keymap.PrevMatch.SetEnabled(b.search.query != nil)
+	m.keymap.NextMatch.SetEnabled(b.search.query != nil)
+	m.keymap.Select.SetEnabled(!m.selecting || b.anchor < 0)
+	m.keymap.PrintSelection.SetEnabled(m.selecting)
+	m.keymap.CancelSelection.SetEnabled(m.selecting && (b.anchor >= 0 || !m.selectOnly))
+	if m.keymap.CancelSelection.Enabled() {
+		m.keymap.Quit.SetHelp("q", "quit")
+	} else {
+		m.keymap.Quit.SetHelp("esc", "quit")
+	}
 }
 
+// helpView shows the keys, after what's going on with the search and the
+// streamed input.
 func (m *model) helpView() string {
-	return m.help.View(m.keymap)
+	var status []string
+	if m.message != "" {
+		status = append(status, m.matchStyle.Render(m.message))
+	}
+	if s := m.selectionStatus(); s != "" {
+		status = append(status, m.matchStyle.Render(s))
+	}
+	if p := m.positionView(); p != "" {
+		status = append(status, m.lineNumberStyle.Render(p))
+	}
+	if s := m.buf().search.status(); s != "" {
+		status = append(status, m.matchStyle.Render(s))
+	}
+	if left := m.buf().left; left > 0 && !m.softWrap {
+		status = append(status, m.lineNumberStyle.Render(fmt.Sprintf("col %d", left+1)))
+	}
+	if m.newLines > 0 {
+		status = append(status, m.matchStyle.Render(fmt.Sprintf("↓ %d new lines", m.newLines)))
+	}
+	status = append(status, m.help.View(m.keymap))
+	return strings.Join(status, m.help.Styles.ShortSeparator.Inline(true).Render(m.help.ShortSeparator))
+}
+
+// refresh lays the view out again at the current window size.
+func (m *model) refresh() {
+	if m.width == 0 && m.height == 0 {
+		return
+	}
+	m.processText(tea.WindowSizeMsg{Width: m.width, Height: m.height})
 }
 
 func (m *model) processText(msg tea.WindowSizeMsg) {
-	m.viewport.SetHeight(msg.Height - lipgloss.Height(m.helpView()))
+	height := msg.Height - lipgloss.Height(m.helpView())
+	if header := m.headerView(); header != "" {
+		height -= lipgloss.Height(header)
+	}
+	m.viewport.SetHeight(height)
 	m.viewport.SetWidth(msg.Width)
-	textStyle := lipgloss.NewStyle().Width(m.viewport.Width())
-	var text strings.Builder
 
 	// Determine max width of a line.
-	m.maxWidth = m.viewport.Width()
-	if m.softWrap {
-		vpStyle := m.viewport.Style
-		m.maxWidth -= vpStyle.GetHorizontalBorderSize() + vpStyle.GetHorizontalMargins() + vpStyle.GetHorizontalPadding()
-		if m.showLineNumbers {
-			m.maxWidth -= lipgloss.Width("     │ ")
-		}
-	}
+	m.textWidth = m.viewport.Width() - m.viewport.Style.GetHorizontalFrameSize() - m.gutterWidth()
 
-	for i, line := range strings.Split(m.content, "\n") {
-		line = strings.ReplaceAll(line, "\t", "    ")
-		if m.showLineNumbers {
-			text.WriteString(m.lineNumberStyle.Render(fmt.Sprintf("%4d │ ", i+1)))
-		}
-		idx := 0
-		if w := ansi.StringWidth(line); m.softWrap && w > m.maxWidth {
-			for w > idx {
-				if m.showLineNumbers && idx != 0 {
-					text.WriteString(m.lineNumberStyle.Render("     │ "))
-				}
-				truncatedLine := ansi.Cut(line, idx, m.maxWidth+idx)
-				idx += m.maxWidth
-				text.WriteString(textStyle.Render(truncatedLine))
-				text.WriteString("\n")
-			}
-		} else {
-			text.WriteString(textStyle.Render(line))
-			text.WriteString("\n")
-		}
-	}
-
-	diffHeight := m.viewport.Height() - lipgloss.Height(text.String())
-	if diffHeight > 0 && m.showLineNumbers {
-		remainingLines := "   ~ │ " + strings.Repeat("\n   ~ │ ", diffHeight-1)
-		text.WriteString(m.lineNumberStyle.Render(remainingLines))
+	// Markdown is rendered again at the new width, staying around the same
+	// place in the document.
+	b := m.buf()
+	before := b.doc.Len()
+	if m.renderMarkdown(m.textWidth) && before > 0 {
+		b.top, b.topRow = b.top*b.doc.Len()/before, 0
 	}
-	m.viewport.SetContent(text.String())
+	m.clamp()
 }
 
-const heightOffset = 2
-
 func (m model) keyHandler(msg tea.KeyPressMsg) (model, tea.Cmd) {
 	km := m.keymap
+	vkm := m.viewport.KeyMap
+	s := &m.buf().search
 	var cmd tea.Cmd
-	if m.search.active {
+	if s.active {
 		switch {
 		case key.Matches(msg, km.ConfirmSearch):
-			if m.search.input.Value() != "" {
-				m.content = m.origContent
-				m.search.Execute(&m)
-
-				// Trigger a view update to highlight the found matches.
-				m.search.NextMatch(&m)
-				m.processText(tea.WindowSizeMsg{Height: m.viewport.Height() + heightOffset, Width: m.viewport.Width()})
-			} else {
-				m.search.Done()
+			// Large documents are only searched once the search is typed.
+			if large(m.buf().doc) {
+				s.Update(&m)
 			}
+			s.Confirm()
 		case key.Matches(msg, km.CancelSearch):
-			m.search.Done()
+			s.Cancel(&m)
 		default:
-			m.search.input, cmd = m.search.input.Update(msg)
+			value := s.input.Value()
+			s.input, cmd = s.input.Update(msg)
+			if s.input.Value() != value && !large(m.buf().doc) {
+				s.Update(&m)
+			}
 		}
-	} else {
+		return m, cmd
+	}
+	if m.gotoInput.Focused() {
+		return m.updateGoto(msg)
+	}
+	if m.countKey(msg) {
+		return m, nil
+	}
+	if m.selecting {
 		switch {
-		case key.Matches(msg, km.Home):
-			m.viewport.GotoTop()
-		case key.Matches(msg, km.End):
-			m.viewport.GotoBottom()
-		case key.Matches(msg, km.Search):
-			m.search.Begin()
-			return m, textinput.Blink
-		case key.Matches(msg, km.PrevMatch):
-			m.search.PrevMatch(&m)
-			m.processText(tea.WindowSizeMsg{Height: m.viewport.Height() + heightOffset, Width: m.viewport.Width()})
-		case key.Matches(msg, km.NextMatch):
-			m.search.NextMatch(&m)
-			m.processText(tea.WindowSizeMsg{Height: m.viewport.Height() + heightOffset, Width: m.viewport.Width()})
-		case key.Matches(msg, km.Quit):
+		case key.Matches(msg, km.PrintSelection):
+			m.printSelection()
 			return m, tea.Quit
-		case key.Matches(msg, km.Abort):
-			return m, tea.Interrupt
+		case key.Matches(msg, km.CancelSelection):
+			m.cancelSelection()
+			return m, nil
+		case m.selectionKey(msg):
+			return m, nil
 		}
-		m.viewport, cmd = m.viewport.Update(msg)
 	}
 
-	return m, cmd
+	switch {
+	case key.Matches(msg, km.Select):
+		m.startSelection()
+	case key.Matches(msg, km.Goto):
+		m.gotoInput.Reset()
+		return m, m.gotoInput.Focus()
+	case key.Matches(msg, km.Home):
+		m.gotoTop()
+	case key.Matches(msg, km.End):
+		m.gotoBottom()
+	case key.Matches(msg, vkm.Down):
+		m.scrollDown(1)
+	case key.Matches(msg, vkm.Up):
+		m.scrollUp(1)
+	case key.Matches(msg, vkm.PageDown):
+		m.scrollDown(m.visibleRows())
+	case key.Matches(msg, vkm.PageUp):
+		m.scrollUp(m.visibleRows())
+	case key.Matches(msg, vkm.HalfPageDown):
+		m.scrollDown(m.visibleRows() / 2)
+	case key.Matches(msg, vkm.HalfPageUp):
+		m.scrollUp(m.visibleRows() / 2)
+	case key.Matches(msg, km.Left):
+		m.scrollLeft(m.horizontalStep)
+	case key.Matches(msg, km.Right):
+		m.scrollRight(m.horizontalStep)
+	case key.Matches(msg, km.NextFile):
+		m.switchBuffer(1)
+	case key.Matches(msg, km.PrevFile):
+		m.switchBuffer(-1)
+	case key.Matches(msg, km.Outline):
+		m.openOutline()
+	case key.Matches(msg, km.Search):
+		s.Begin(&m)
+		return m, textinput.Blink
+	case key.Matches(msg, km.PrevMatch):
+		s.PrevMatch(&m)
+	case key.Matches(msg, km.NextMatch):
+		s.NextMatch(&m)
+	case key.Matches(msg, km.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, km.Abort):
+		return m, tea.Interrupt
+	}
+	return m, nil
 }
 
 func (m model) View() tea.View {
 	v := tea.NewView("")
 	v.AltScreen = true
 	v.ReportFocus = true
-	if m.search.active {
-		v.SetContent(m.viewport.View() + "\n " + m.search.input.View())
+	m.viewport.SetContent(m.windowView())
+	view := m.viewport.View()
+	if header := m.headerView(); header != "" {
+		view = header + "\n" + view
+	}
+	if s := m.buf().search; s.active {
+		input := s.input.View()
+		if status := s.status(); status != "" {
+			input += " " + m.lineNumberStyle.Render(status)
+		}
+		v.SetContent(view + "\n " + input)
+		return v
+	}
+	if m.gotoInput.Focused() {
+		v.SetContent(view + "\n " + m.gotoInput.View())
 		return v
 	}
 
-	v.SetContent(m.viewport.View() + "\n" + m.helpView())
+	if m.outline {
+		view = lipgloss.NewCompositor(
+			lipgloss.NewLayer(view),
+			lipgloss.NewLayer(m.outlineView()).X(2).Y(lipgloss.Height(m.headerView())+1).Z(1),
+		).Render()
+	}
+	v.SetContent(view + "\n" + m.helpView())
 	return v
 }
@@ -0,0 +1,687 @@
+package pager
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"charm.land/bubbles/v2/help"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// failingReader returns its data, then an error instead of io.EOF.
+type failingReader struct {
+	data string
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.data == "" {
+		return 0, errors.New("broken pipe")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestStream(t *testing.T) {
+	for name, tt := range map[string]struct {
+		in    string
+		lines []string
+	}{
+		"empty":                {in: "", lines: nil},
+		"trailing newline":     {in: "a\nb\n", lines: []string{"a", "b"}},
+		"no trailing newline":  {in: "a\nb", lines: []string{"a", "b"}},
+		"empty lines":          {in: "\n\na\n\n", lines: []string{"", "", "a", ""}},
+		"crlf":                 {in: "a\r\nb\r\n", lines: []string{"a", "b"}},
+		"lone carriage return": {in: "a\rb\n", lines: []string{"a\rb"}},
+		"overstrike":           {in: "b\bbo\bo\n", lines: []string{"bo"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := newStream(strings.NewReader(tt.in), false, overstrike{})
+			var lines []string
+			for {
+				msg := s.wait()().(streamMsg)
+				lines = append(lines, msg.lines...)
+				if msg.done {
+					if msg.err != nil {
+						t.Fatalf("unexpected error: %v", msg.err)
+					}
+					break
+				}
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("expected %q, got %q", tt.lines, lines)
+			}
+		})
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	s := newStream(&failingReader{data: "a\nb"}, false, overstrike{})
+	var lines []string
+	for line := range s.lines {
+		lines = append(lines, line)
+	}
+	if !reflect.DeepEqual(lines, []string{"a"}) {
+		t.Errorf("expected the complete lines only, got %q", lines)
+	}
+	if s.err == nil || errors.Is(s.err, io.EOF) {
+		t.Errorf("expected a read error, got %v", s.err)
+	}
+}
+
+// lines returns all the lines of a document as they're displayed.
+func lines(doc document) []string {
+	out := make([]string, 0, doc.Len())
+	for i := range doc.Len() {
+		out = append(out, doc.Line(i))
+	}
+	return out
+}
+
+func TestOpenBuffers(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	if err := os.WriteFile(first, []byte("a\nb\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("c"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "typo.log")
+
+	for name, tt := range map[string]struct {
+		args  []string
+		names []string
+		lines [][]string
+		err   bool
+	}{
+		"file":             {args: []string{first}, names: []string{first}, lines: [][]string{{"a", "b"}}},
+		"files":            {args: []string{first, second}, names: []string{first, second}, lines: [][]string{{"a", "b"}, {"c"}}},
+		"text":             {args: []string{"hello\nworld"}, names: []string{""}, lines: [][]string{{"hello", "world"}}},
+		"single-line text": {args: []string{"hello world"}, names: []string{""}, lines: [][]string{{"hello world"}}},
+		"missing among":    {args: []string{first, missing}, err: true},
+		"unreadable":       {args: []string{dir}, err: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			buffers, err := openBuffers(tt.args, overstrike{})
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			var got [][]string
+			for _, b := range buffers {
+				names = append(names, b.name)
+				got = append(got, lines(b.doc))
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("expected buffers %q, got %q", tt.names, names)
+			}
+			if !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("expected lines %q, got %q", tt.lines, got)
+			}
+		})
+	}
+}
+
+func TestHighlighter(t *testing.T) {
+	for name, tt := range map[string]struct {
+		language string
+		theme    string
+		file     string
+		lines    []string
+		lexer    string
+		err      bool
+	}{
+		"file name":     {file: "main.go", lines: []string{"x"}, lexer: "Go"},
+		"content":       {lines: []string{"#!/bin/bash", "echo hi"}, lexer: "Bash"},
+		"language":      {language: "python", file: "main.go", lexer: "Python"},
+		"plain text":    {language: "text", lines: []string{"#!/bin/bash"}},
+		"text file":     {file: "notes.txt", lines: []string{"hello"}},
+		"undetected":    {lines: []string{"hello world"}},
+		"other theme":   {theme: "dracula", file: "main.go", lexer: "Go"},
+		"unknown lang":  {language: "klingon", err: true},
+		"unknown theme": {theme: "nope", file: "main.go", err: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			o := Options{Language: tt.language, SyntaxTheme: cmp.Or(tt.theme, "monokai")}
+			h, err := o.highlighter(tt.file, tt.lines)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var lexer string
+			if h != nil {
+				lexer = h.lexer.Config().Name
+			}
+			if lexer != tt.lexer {
+				t.Errorf("expected lexer %q, got %q", tt.lexer, lexer)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	h, err := Options{SyntaxTheme: "monokai"}.highlighter("main.go", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	in := []string{"package main", "", "func main() {}"}
+	got := h.highlight(in)
+	if len(got) != len(in) {
+		t.Fatalf("expected %d lines, got %q", len(in), got)
+	}
+	for i := range in {
+		if ansi.Strip(got[i]) != in[i] {
+			t.Errorf("expected line %q, got %q", in[i], ansi.Strip(got[i]))
+		}
+	}
+	if got[0] == in[0] {
+		t.Error("expected the line to be colored")
+	}
+
+	var none *highlighter
+	if got := none.highlight(in); !reflect.DeepEqual(got, in) {
+		t.Errorf("expected plain lines, got %q", got)
+	}
+}
+
+func TestFindHeadings(t *testing.T) {
+	for name, tt := range map[string]struct {
+		source   string
+		lines    []string
+		headings []heading
+	}{
+		"levels": {
+			source:   "# Title\n\ntext\n\n## Section\n###### Deep ##",
+			lines:    []string{"  Title", "", "  text", "", "  ## Section", "  ###### Deep"},
+			headings: []heading{{1, "Title", 0}, {2, "Section", 4}, {6, "Deep", 5}},
+		},
+		"fenced code": {
+			source:   "# Title\n```sh\n# comment\n```\n~~~\n# other\n~~~\n## Next",
+			lines:    []string{"Title", "# comment", "# other", "## Next"},
+			headings: []heading{{1, "Title", 0}, {2, "Next", 3}},
+		},
+		"inline code": {
+			source:   "## Run `gum` **now**",
+			lines:    []string{"## Run gum now"},
+			headings: []heading{{2, "Run gum now", 0}},
+		},
+		"repeated": {
+			source:   "# Usage\n# Usage",
+			lines:    []string{"Usage", "text", "Usage"},
+			headings: []heading{{1, "Usage", 0}, {1, "Usage", 2}},
+		},
+		"missing": {
+			source:   "# Gone\n# Kept",
+			lines:    []string{"Kept"},
+			headings: []heading{{1, "Kept", 0}},
+		},
+		"not headings": {
+			source: "#hashtag\n    # indented code",
+			lines:  []string{"#hashtag", "# indented code"},
+		},
+		"styled": {
+			source:   "# Title",
+			lines:    []string{"\x1b[1mTitle\x1b[0m"},
+			headings: []heading{{1, "Title", 0}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := findHeadings(tt.source, tt.lines)
+			if !reflect.DeepEqual(got, tt.headings) {
+				t.Errorf("expected %+v, got %+v", tt.headings, got)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	for name, tt := range map[string]struct {
+		lines     []string
+		value     string
+		literal   bool
+		smartCase bool
+		matches   []match
+		err       bool
+	}{
+		"case sensitive":   {lines: []string{"Foo foo"}, value: "foo", matches: []match{{0, 4, 7}}},
+		"smart case":       {lines: []string{"Foo foo FOO"}, value: "foo", smartCase: true, matches: []match{{0, 0, 3}, {0, 4, 7}, {0, 8, 11}}},
+		"smart case upper": {lines: []string{"Foo foo FOO"}, value: "Foo", smartCase: true, matches: []match{{0, 0, 3}}},
+		"changing case":    {lines: []string{"İab"}, value: "ab", smartCase: true, matches: []match{{0, 2, 4}}},
+		"regex":            {lines: []string{"a.b axb"}, value: "a.b", matches: []match{{0, 0, 3}, {0, 4, 7}}},
+		"literal":          {lines: []string{"a.b axb"}, value: "a.b", literal: true, matches: []match{{0, 0, 3}}},
+		"literal brackets": {lines: []string{"f(x)"}, value: "(x", literal: true, matches: []match{{0, 1, 3}}},
+		"invalid regex":    {lines: []string{"f(x)"}, value: "(x", err: true},
+		"empty":            {lines: []string{"a"}, value: "", err: true},
+		"zero width":       {lines: []string{"axxb", "b"}, value: "x*", matches: []match{{0, 1, 3}}},
+		"order":            {lines: []string{"ab", "", "b a"}, value: "a|b", matches: []match{{0, 0, 1}, {0, 1, 2}, {2, 0, 1}, {2, 2, 3}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := search{literal: tt.literal, smartCase: tt.smartCase}
+			err := s.compile(tt.value)
+			if tt.err {
+				if err == nil || s.query != nil {
+					t.Fatalf("expected an error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s.find(newMemory(tt.lines), 0)
+			if !reflect.DeepEqual(s.matches, tt.matches) {
+				t.Errorf("expected %v, got %v", tt.matches, s.matches)
+			}
+		})
+	}
+}
+
+func TestSearchFrom(t *testing.T) {
+	s := search{}
+	if err := s.compile("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.find(newMemory([]string{"a", "a", "a"}), 1)
+	if expect := []match{{1, 0, 1}, {2, 0, 1}}; !reflect.DeepEqual(s.matches, expect) {
+		t.Errorf("expected %v, got %v", expect, s.matches)
+	}
+}
+
+func TestSearchStatus(t *testing.T) {
+	s := search{}
+	if got := s.status(); got != "" {
+		t.Errorf("expected no status without a search, got %q", got)
+	}
+	if err := s.compile("x"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.find(newMemory([]string{"a"}), 0)
+	if got := s.status(); got != "no matches" {
+		t.Errorf("expected %q, got %q", "no matches", got)
+	}
+	s.find(newMemory([]string{"x", "x", "x"}), 0)
+	s.current = 1
+	if got := s.status(); got != "2/3" {
+		t.Errorf("expected %q, got %q", "2/3", got)
+	}
+}
+
+// indexText writes the text to a file and indexes it.
+func indexText(t *testing.T, text string) *indexed {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() }) //nolint:errcheck
+	d, err := indexFile(f, overstrike{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return d
+}
+
+func TestIndexFile(t *testing.T) {
+	for name, tt := range map[string]struct {
+		text    string
+		offsets []int64
+		lines   []string
+	}{
+		"empty":               {text: "", offsets: []int64{0}, lines: []string{}},
+		"trailing newline":    {text: "ab\nc\n", offsets: []int64{0, 3, 5}, lines: []string{"ab", "c"}},
+		"no trailing newline": {text: "ab\nc", offsets: []int64{0, 3, 4}, lines: []string{"ab", "c"}},
+		"empty lines":         {text: "\n\na", offsets: []int64{0, 1, 2, 3}, lines: []string{"", "", "a"}},
+		"crlf":                {text: "ab\r\nc\r\n", offsets: []int64{0, 4, 7}, lines: []string{"ab", "c"}},
+		"tabs":                {text: "a\tb\n", offsets: []int64{0, 4}, lines: []string{"a    b"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := indexText(t, tt.text)
+			if !reflect.DeepEqual(d.offsets, tt.offsets) {
+				t.Errorf("expected offsets %v, got %v", tt.offsets, d.offsets)
+			}
+			if got := lines(d); !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("expected lines %q, got %q", tt.lines, got)
+			}
+			scanned := []string{}
+			d.Scan(0, func(_ int, plain string) { scanned = append(scanned, plain) })
+			if !reflect.DeepEqual(scanned, tt.lines) {
+				t.Errorf("expected scanned lines %q, got %q", tt.lines, scanned)
+			}
+		})
+	}
+}
+
+func TestIndexedCache(t *testing.T) {
+	var b strings.Builder
+	n := maxCachedLines + 10
+	for i := range n {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	d := indexText(t, b.String())
+	for i := range n {
+		d.Line(i)
+	}
+	if len(d.cache) > maxCachedLines {
+		t.Errorf("expected at most %d cached lines, got %d", maxCachedLines, len(d.cache))
+	}
+	if _, ok := d.cache[0]; ok {
+		t.Error("expected the first line to be evicted")
+	}
+	if got := d.Line(0); got != "line 0" {
+		t.Errorf("expected the evicted line to be read again, got %q", got)
+	}
+	if got := d.Line(n - 1); got != fmt.Sprintf("line %d", n-1) {
+		t.Errorf("expected the last line, got %q", got)
+	}
+}
+
+// pagerModel returns a model paging through n numbered lines, in a terminal
+// of 40 by 10 cells.
+func pagerModel(n int) model {
+	text := make([]string, n)
+	for i := range text {
+		text[i] = fmt.Sprintf("line %d", i+1)
+	}
+	m := model{
+		viewport: viewport.New(),
+		help:     help.New(),
+		keymap:   defaultKeymap(),
+		buffers:  []buffer{{doc: newMemory(text), anchor: -1}},
+		width:    40,
+		height:   10,
+	}
+	m.refresh()
+	return m
+}
+
+// keyPress returns the message of a key typed.
+func keyPress(k string) tea.KeyPressMsg {
+	switch k {
+	case "esc":
+		return tea.KeyPressMsg{Code: tea.KeyEscape}
+	case "enter":
+		return tea.KeyPressMsg{Code: tea.KeyEnter}
+	}
+	r := []rune(k)[0]
+	return tea.KeyPressMsg{Code: r, Text: k}
+}
+
+func TestScrollSideways(t *testing.T) {
+	text := make([]string, 30)
+	for i := range text {
+		text[i] = fmt.Sprintf("line %d", i+1)
+	}
+	wide := strings.Repeat("0123456789", 6)
+	text[20] = wide
+
+	for name, tt := range map[string]struct {
+		top    int
+		steps  []int
+		left   int
+		row    string
+		status string
+	}{
+		"right":          {top: 15, steps: []int{10}, left: 10, row: "  21 │ " + wide[10:43], status: "col 11"},
+		"widest line":    {top: 15, steps: []int{100}, left: 27, row: "  21 │ " + wide[27:], status: "col 28"},
+		"back left":      {top: 15, steps: []int{10, -4}, left: 6, row: "  21 │ " + wide[6:39], status: "col 7"},
+		"past the start": {top: 15, steps: []int{10, -20}, left: 0, row: "  21 │ " + wide[:33]},
+		"out of view":    {steps: []int{10}, left: 0, row: "  21 │ " + wide[:33]},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := pagerModel(0)
+			m.buffers = []buffer{{doc: newMemory(text), anchor: -1}}
+			m.showLineNumbers = true
+			m.refresh()
+			m.scrollTo(tt.top, 0)
+			for _, n := range tt.steps {
+				if n > 0 {
+					m.scrollRight(n)
+				} else {
+					m.scrollLeft(-n)
+				}
+			}
+			if got := m.buf().left; got != tt.left {
+				t.Errorf("expected column %d, got %d", tt.left, got)
+			}
+			if got := m.renderLine(20); !reflect.DeepEqual(got, []string{tt.row}) {
+				t.Errorf("expected row %q, got %q", tt.row, got)
+			}
+			status := ansi.Strip(m.helpView())
+			if tt.status != "" && !strings.Contains(status, tt.status) {
+				t.Errorf("expected %q in the status, got %q", tt.status, status)
+			}
+			if tt.status == "" && strings.Contains(status, "col ") {
+				t.Errorf("expected no column in the status, got %q", status)
+			}
+		})
+	}
+}
+
+func TestCountKey(t *testing.T) {
+	m := pagerModel(100)
+	bottom, _ := m.bottom()
+
+	for name, tt := range map[string]struct {
+		keys    []string
+		top     int
+		handled bool
+		count   string
+	}{
+		"line":          {keys: []string{"4", "2", "G"}, top: 41, handled: true},
+		"first line":    {keys: []string{"1", "g"}, top: 0, handled: true},
+		"past the end":  {keys: []string{"1", "2", "3", "G"}, top: bottom, handled: true},
+		"percentage":    {keys: []string{"5", "0", "%"}, top: 50, handled: true},
+		"over 100%":     {keys: []string{"2", "0", "0", "%"}, top: bottom, handled: true},
+		"counting":      {keys: []string{"1", "0"}, top: 10, handled: true, count: "10"},
+		"zero":          {keys: []string{"0"}, top: 10},
+		"without count": {keys: []string{"G"}, top: 10},
+		"percent alone": {keys: []string{"%"}, top: 10},
+		"escape":        {keys: []string{"5", "esc"}, top: 10, handled: true},
+		"other key":     {keys: []string{"5", "j"}, top: 10},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := m
+			m.buffers = slices.Clone(m.buffers)
+			m.scrollTo(10, 0)
+			var handled bool
+			for _, k := range tt.keys {
+				handled = m.countKey(keyPress(k))
+			}
+			if handled != tt.handled {
+				t.Errorf("expected the last key to be handled: %v, got %v", tt.handled, handled)
+			}
+			if top := m.buf().top; top != tt.top {
+				t.Errorf("expected line %d at the top, got %d", tt.top, top)
+			}
+			if m.count != tt.count {
+				t.Errorf("expected count %q, got %q", tt.count, m.count)
+			}
+		})
+	}
+}
+
+func TestJump(t *testing.T) {
+	m := pagerModel(100)
+	bottom, _ := m.bottom()
+
+	for name, tt := range map[string]struct {
+		value   string
+		top     int
+		message string
+	}{
+		"line":       {value: "42", top: 41},
+		"spaces":     {value: " 42 ", top: 41},
+		"zero":       {value: "0", top: 0},
+		"past end":   {value: "1000", top: bottom},
+		"percentage": {value: "50%", top: 50},
+		"empty":      {value: "", top: 10},
+		"negative":   {value: "-3", top: 10, message: `invalid line "-3"`},
+		"text":       {value: "abc", top: 10, message: `invalid line "abc"`},
+		"percent":    {value: "%", top: 10, message: `invalid line "%"`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := m
+			m.buffers = slices.Clone(m.buffers)
+			m.scrollTo(10, 0)
+			m.jump(tt.value)
+			if top := m.buf().top; top != tt.top {
+				t.Errorf("expected line %d at the top, got %d", tt.top, top)
+			}
+			if m.message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, m.message)
+			}
+		})
+	}
+}
+
+func TestMarks(t *testing.T) {
+	m := pagerModel(100)
+	press := func(keys ...string) bool {
+		var handled bool
+		for _, k := range keys {
+			handled = m.countKey(keyPress(k))
+		}
+		return handled
+	}
+
+	m.scrollTo(20, 0)
+	if !press("m", "a") || m.message != "mark a set" {
+		t.Fatalf("expected the mark to be set, got %q", m.message)
+	}
+	m.scrollTo(0, 0)
+	if !press("'", "a") || m.buf().top != 20 {
+		t.Errorf("expected to go back to line 20, got %d", m.buf().top)
+	}
+	if !press("'", "b") || m.message != "mark b not set" {
+		t.Errorf("expected the mark not to be set, got %q", m.message)
+	}
+
+	// Escape cancels the pending mark, the next letter is a key of its own.
+	m.scrollTo(50, 0)
+	if !press("m", "esc") || m.pending != "" {
+		t.Errorf("expected escape to cancel the mark, pending %q", m.pending)
+	}
+	if press("c") {
+		t.Error("expected the letter after escape not to be used")
+	}
+	if _, ok := m.buf().marks['c']; ok {
+		t.Error("expected mark c not to be set")
+	}
+
+	// Marks belong to their buffer.
+	m.buffers = append(m.buffers, buffer{doc: newMemory([]string{"a", "b"}), anchor: -1})
+	m.current = 1
+	if !press("'", "a") || m.message != "mark a not set" {
+		t.Errorf("expected marks of other buffers to be ignored, got %q", m.message)
+	}
+}
+
+func TestSelectLines(t *testing.T) {
+	text := make([]string, 20)
+	for i := range text {
+		text[i] = fmt.Sprintf("\x1b[1mline %d\x1b[0m", i+1)
+	}
+
+	for name, tt := range map[string]struct {
+		selectOnly  bool
+		top         int
+		keys        []string
+		first, last int
+		selecting   bool
+		output      []string
+	}{
+		"range":            {keys: []string{"v", "j", "j"}, first: 0, last: 2, selecting: true},
+		"upwards":          {top: 5, keys: []string{"v", "k", "k"}, first: 3, last: 5, selecting: true},
+		"past the top":     {top: 1, keys: []string{"v", "k", "k"}, first: 0, last: 1, selecting: true},
+		"cancelled":        {keys: []string{"v", "j", "esc"}, first: 1, last: 1},
+		"cursor only":      {selectOnly: true, keys: []string{"j", "j"}, first: 2, last: 2, selecting: true},
+		"anchor dropped":   {selectOnly: true, keys: []string{"j", "v", "j", "esc"}, first: 2, last: 2, selecting: true},
+		"printed":          {keys: []string{"v", "j", "enter"}, first: 0, last: 1, selecting: true, output: []string{"line 1", "line 2"}},
+		"printed the line": {selectOnly: true, keys: []string{"j", "enter"}, first: 1, last: 1, selecting: true, output: []string{"line 2"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := pagerModel(0)
+			m.buffers = []buffer{{doc: newMemory(text), anchor: -1}}
+			m.selecting, m.selectOnly = tt.selectOnly, tt.selectOnly
+			m.refresh()
+			m.scrollTo(tt.top, 0)
+			m.updateKeymap()
+
+			var quit bool
+			for _, k := range tt.keys {
+				tm, cmd := m.Update(keyPress(k))
+				m = tm.(model)
+				if cmd != nil {
+					_, quit = cmd().(tea.QuitMsg)
+				}
+			}
+			if first, last := m.selection(); first != tt.first || last != tt.last {
+				t.Errorf("expected lines %d to %d, got %d to %d", tt.first, tt.last, first, last)
+			}
+			if m.selecting != tt.selecting {
+				t.Errorf("expected selecting to be %v", tt.selecting)
+			}
+			if quit != (tt.output != nil) {
+				t.Errorf("expected quitting to be %v", tt.output != nil)
+			}
+			if !reflect.DeepEqual(m.output, tt.output) {
+				t.Errorf("expected output %q, got %q", tt.output, m.output)
+			}
+		})
+	}
+}
+
+func TestOverstrike(t *testing.T) {
+	mark := func(open, close string) lipgloss.Style {
+		return lipgloss.NewStyle().Transform(func(s string) string { return open + s + close })
+	}
+	o := overstrike{bold: mark("<b>", "</b>"), underline: mark("<u>", "</u>")}
+
+	for name, tt := range map[string]struct {
+		in, out string
+	}{
+		"plain":              {in: "plain text", out: "plain text"},
+		"bold":               {in: "N\bNA\bAM\bME\bE", out: "<b>NAME</b>"},
+		"underline":          {in: "_\bf_\bi_\bl_\be", out: "<u>file</u>"},
+		"underline after":    {in: "f\b_", out: "<u>f</u>"},
+		"underscore":         {in: "_\b_", out: "<b>_</b>"},
+		"bold and underline": {in: "_\bX\bX", out: "<b><u>X</u></b>"},
+		"mixed":              {in: "a B\bB _\bc d", out: "a <b>B</b> <u>c</u> d"},
+		"wide":               {in: "日\b日本", out: "<b>日</b>本"},
+		"stray backspaces":   {in: "\ba\b", out: "a"},
+		"backspace only":     {in: "\b", out: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := o.render(tt.in); got != tt.out {
+				t.Errorf("expected %q, got %q", tt.out, got)
+			}
+		})
+	}
+}
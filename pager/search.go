@@ -3,20 +3,41 @@ package pager
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"unicode"
 
 	"charm.land/bubbles/v2/textinput"
 	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/x/ansi"
 )
 
+// search finds matches on the plain text of the lines, so that the colors of
+// the content don't get in the way, and highlights them when rendering.
 type search struct {
-	active           bool
-	input            textinput.Model
-	query            *regexp.Regexp
-	matchIndex       int
-	matchLipglossStr string
-	matchString      string
+	active    bool
+	input     textinput.Model
+	literal   bool
+	smartCase bool
+	query     *regexp.Regexp
+	matches   []match
+
+	// Case is ignored by matching the query on lowercase text, which is much
+	// faster than a case insensitive query. The latter is only used on the
+	// lines which change size when lowered.
+	ignoreCase bool
+	folded     *regexp.Regexp
+
+	current int
+
+	// Where the search started, to go back there when it's cancelled.
+	origin, originRow int
+	previous          *search
+}
+
+// match is where a match was found in the plain text of a line, in bytes.
+type match struct {
+	line, start, end int
 }
 
 func (s *search) new() {
@@ -30,149 +51,179 @@ func (s *search) new() {
 	s.input = input
 }
 
-func (s *search) Begin() {
+// Begin starts typing a new search from the current position.
+func (s *search) Begin(m *model) {
+	previous := *s
+	s.previous = &previous
+	b := m.buf()
+	s.origin, s.originRow = b.top, b.topRow
 	s.new()
 	s.active = true
 	s.input.Focus()
 }
 
-// Execute find all lines in the model with a match.
-func (s *search) Execute(m *model) {
-	defer s.Done()
-	if s.input.Value() == "" {
-		s.query = nil
-		return
+// Update searches for what was typed so far, showing the first match after
+// the position the search started from.
+func (s *search) Update(m *model) {
+	s.matches = nil
+	s.current = -1
+	if err := s.compile(s.input.Value()); err == nil {
+		s.find(m.buf().doc, 0)
 	}
 
-	var err error
-	s.query, err = regexp.Compile(s.input.Value())
-	if err != nil {
-		s.query = nil
-		return
+	m.scrollTo(s.origin, s.originRow)
+	s.current = sort.Search(len(s.matches), func(i int) bool { return s.matches[i].line >= s.origin })
+	if s.current == len(s.matches) && len(s.matches) > 0 {
+		s.current = 0
 	}
-	query := regexp.MustCompile(fmt.Sprintf("(%s)", s.query.String()))
-	m.content = query.ReplaceAllString(m.content, m.matchStyle.Render("$1"))
-
-	// Recompile the regex to match the an replace the highlights.
-	leftPad, _ := lipglossPadding(m.matchStyle)
-	matchingString := regexp.QuoteMeta(m.matchStyle.Render()[:leftPad]) + s.query.String() + regexp.QuoteMeta(m.matchStyle.Render()[leftPad:])
-	s.query, err = regexp.Compile(matchingString)
-	if err != nil {
-		s.query = nil
+	if s.current < len(s.matches) {
+		m.showMatch(s.matches[s.current])
 	}
 }
 
-func (s *search) Done() {
+// Confirm keeps the search typed.
+func (s *search) Confirm() {
 	s.active = false
-
-	// To account for the first match is always executed.
-	s.matchIndex = -1
+	s.previous = nil
 }
 
-func (s *search) NextMatch(m *model) {
-	// Check that we are within bounds.
-	if s.query == nil {
-		return
+// Cancel goes back to the previous search and position.
+func (s *search) Cancel(m *model) {
+	line, row := s.origin, s.originRow
+	if s.previous != nil {
+		*s = *s.previous
 	}
+	s.active = false
+	s.previous = nil
+	m.scrollTo(line, row)
+}
 
-	// Remove previous highlight.
-	m.content = strings.Replace(m.content, s.matchLipglossStr, s.matchString, 1)
-
-	// Highlight the next match.
-	allMatches := s.query.FindAllStringIndex(m.content, -1)
-	if len(allMatches) == 0 {
-		return
+// compile turns the text typed into a query. It ignores the case unless
+// the text has uppercase letters, when smart case is on.
+func (s *search) compile(value string) error {
+	s.query = nil
+	if value == "" {
+		return fmt.Errorf("empty search")
 	}
-
-	leftPad, rightPad := lipglossPadding(m.matchStyle)
-	s.matchIndex = (s.matchIndex + 1) % len(allMatches)
-	match := allMatches[s.matchIndex]
-	lhs := m.content[:match[0]]
-	rhs := m.content[match[0]:]
-	s.matchString = m.content[match[0]:match[1]]
-	s.matchLipglossStr = m.matchHighlightStyle.Render(s.matchString[leftPad : len(s.matchString)-rightPad])
-	m.content = lhs + strings.Replace(rhs, m.content[match[0]:match[1]], s.matchLipglossStr, 1)
-
-	// Update the viewport position.
-	var line int
-	formatStr := softWrapEm(m.content, m.maxWidth, m.softWrap)
-	index := strings.Index(formatStr, s.matchLipglossStr)
-	if index != -1 {
-		line = strings.Count(formatStr[:index], "\n")
+	pattern := value
+	if s.literal {
+		pattern = regexp.QuoteMeta(value)
 	}
+	query, err := regexp.Compile(pattern)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	s.query = query
+	s.ignoreCase = s.smartCase && !hasUpper(value)
+	if s.ignoreCase {
+		s.folded = regexp.MustCompile("(?i)" + pattern)
+	}
+	return nil
+}
 
-	// Only update if the match is not within the viewport.
-	if index != -1 && (line > m.viewport.YOffset()-1+m.viewport.VisibleLineCount()-1 || line < m.viewport.YOffset()) {
-		m.viewport.SetYOffset(line)
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
 	}
+	return false
 }
 
-func (s *search) PrevMatch(m *model) {
-	// Check that we are within bounds.
+// find adds the matches on the lines of a document starting at from.
+func (s *search) find(doc document, from int) {
 	if s.query == nil {
 		return
 	}
+	doc.Scan(from, func(i int, plain string) {
+		query := s.query
+		if s.ignoreCase {
+			if lower := strings.ToLower(plain); len(lower) == len(plain) {
+				plain = lower
+			} else {
+				query = s.folded
+			}
+		}
+		for _, loc := range query.FindAllStringIndex(plain, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			s.matches = append(s.matches, match{line: i, start: loc[0], end: loc[1]})
+		}
+	})
+}
 
-	// Remove previous highlight.
-	m.content = strings.Replace(m.content, s.matchLipglossStr, s.matchString, 1)
+// status counts the matches, along with the current one.
+func (s *search) status() string {
+	if s.query == nil {
+		return ""
+	}
+	if len(s.matches) == 0 {
+		return "no matches"
+	}
+	return fmt.Sprintf("%d/%d", s.current+1, len(s.matches))
+}
 
-	// Highlight the previous match.
-	allMatches := s.query.FindAllStringIndex(m.content, -1)
-	if len(allMatches) == 0 {
+func (s *search) NextMatch(m *model) {
+	if len(s.matches) == 0 {
 		return
 	}
+	s.current = (s.current + 1) % len(s.matches)
+	m.showMatch(s.matches[s.current])
+}
 
-	s.matchIndex = (s.matchIndex - 1) % len(allMatches)
-	if s.matchIndex < 0 {
-		s.matchIndex = len(allMatches) - 1
+func (s *search) PrevMatch(m *model) {
+	if len(s.matches) == 0 {
+		return
 	}
-
-	leftPad, rightPad := lipglossPadding(m.matchStyle)
-	match := allMatches[s.matchIndex]
-	lhs := m.content[:match[0]]
-	rhs := m.content[match[0]:]
-	s.matchString = m.content[match[0]:match[1]]
-	s.matchLipglossStr = m.matchHighlightStyle.Render(s.matchString[leftPad : len(s.matchString)-rightPad])
-	m.content = lhs + strings.Replace(rhs, m.content[match[0]:match[1]], s.matchLipglossStr, 1)
-
-	// Update the viewport position.
-	var line int
-	formatStr := softWrapEm(m.content, m.maxWidth, m.softWrap)
-	index := strings.Index(formatStr, s.matchLipglossStr)
-	if index != -1 {
-		line = strings.Count(formatStr[:index], "\n")
+	s.current--
+	if s.current < 0 {
+		s.current = len(s.matches) - 1
 	}
+	m.showMatch(s.matches[s.current])
+}
 
-	// Only update if the match is not within the viewport.
-	if index != -1 && (line > m.viewport.YOffset()-1+m.viewport.VisibleLineCount()-1 || line < m.viewport.YOffset()) {
-		m.viewport.SetYOffset(line)
+// showMatch scrolls to a match, unless it's already in view.
+func (m *model) showMatch(match match) {
+	b := m.buf()
+	plain := b.doc.Plain(match.line)
+	start := ansi.StringWidth(plain[:match.start])
+	row := 0
+	switch {
+	case m.textWidth <= 0:
+	case m.softWrap:
+		row = start / m.textWidth
+	case start < b.left || start+ansi.StringWidth(plain[match.start:match.end]) > b.left+m.textWidth:
+		// Leave a step of context before matches scrolled to sideways.
+		b.left = max(start-m.horizontalStep, 0)
+	}
+	if !m.inView(match.line, row) {
+		m.scrollTo(match.line, row)
 	}
+	b.cursor = match.line
 }
 
-func softWrapEm(str string, maxWidth int, softWrap bool) string {
-	var text strings.Builder
-	for _, line := range strings.Split(str, "\n") {
-		idx := 0
-		if w := ansi.StringWidth(line); softWrap && w > maxWidth {
-			for w > idx {
-				truncatedLine := ansi.Cut(line, idx, maxWidth+idx)
-				idx += maxWidth
-				text.WriteString(truncatedLine)
-				text.WriteString("\n")
-			}
-		} else {
-			text.WriteString(line)
-			text.WriteString("\n")
-		}
+// highlightMatches styles the matches on a line on top of its own colors.
+func (m *model) highlightMatches(i int, line string) string {
+	s := &m.buf().search
+	matches := s.matches
+	first := sort.Search(len(matches), func(j int) bool { return matches[j].line >= i })
+	if first == len(matches) || matches[first].line != i {
+		return line
 	}
 
-	return text.String()
-}
-
-// lipglossPadding calculates how much padding a string is given by a style.
-func lipglossPadding(style lipgloss.Style) (int, int) {
-	render := style.Render(" ")
-	before := strings.Index(render, " ")
-	after := len(render) - len(" ") - before
-	return before, after
+	plain := m.buf().doc.Plain(i)
+	width := ansi.StringWidth(line)
+	for j := first; j < len(matches) && matches[j].line == i; j++ {
+		style := m.matchStyle
+		if j == s.current {
+			style = m.matchHighlightStyle
+		}
+		start := ansi.StringWidth(plain[:matches[j].start])
+		end := start + ansi.StringWidth(plain[matches[j].start:matches[j].end])
+		line = ansi.Cut(line, 0, start) +
+			style.Render(plain[matches[j].start:matches[j].end]) +
+			ansi.Cut(line, end, width)
+	}
+	return line
 }
@@ -0,0 +1,113 @@
+package pager
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+)
+
+// Lines are selected from the anchor to the cursor, which moves instead of
+// the view while selecting. Without an anchor, only the cursor is selected.
+
+// startSelection anchors the selection on the cursor, or on the first line in
+// view when the cursor isn't shown yet.
+func (m *model) startSelection() {
+	b := m.buf()
+	if !m.selecting {
+		b.cursor = b.top
+	}
+	m.selecting = true
+	b.anchor = b.cursor
+}
+
+// cancelSelection drops the anchor, and stops selecting unless that's what
+// the pager is for.
+func (m *model) cancelSelection() {
+	m.buf().anchor = -1
+	m.selecting = m.selectOnly
+}
+
+// selection returns the first and last lines selected.
+func (m *model) selection() (int, int) {
+	b := m.buf()
+	if b.anchor < 0 {
+		return b.cursor, b.cursor
+	}
+	return min(b.anchor, b.cursor), max(b.anchor, b.cursor)
+}
+
+// selected reports whether a line is selected.
+func (m *model) selected(i int) bool {
+	if !m.selecting {
+		return false
+	}
+	first, last := m.selection()
+	return i >= first && i <= last
+}
+
+// moveCursor moves the cursor n lines, scrolling to keep it in view.
+func (m *model) moveCursor(n int) {
+	b := m.buf()
+	if b.doc.Len() == 0 {
+		return
+	}
+	b.cursor = max(min(b.cursor+n, b.doc.Len()-1), 0)
+	if b.cursor < b.top {
+		b.top, b.topRow = b.cursor, 0
+		return
+	}
+	for b.top < b.cursor && !m.inView(b.cursor, m.rowsOf(b.cursor)-1) {
+		m.scrollDown(1)
+	}
+}
+
+// selectionKey handles the keys moving the cursor while selecting. It reports
+// whether the key was used.
+func (m *model) selectionKey(msg tea.KeyPressMsg) bool {
+	vkm := m.viewport.KeyMap
+	switch {
+	case key.Matches(msg, m.keymap.Home):
+		m.moveCursor(-m.buf().doc.Len())
+	case key.Matches(msg, m.keymap.End):
+		m.moveCursor(m.buf().doc.Len())
+	case key.Matches(msg, vkm.Down):
+		m.moveCursor(1)
+	case key.Matches(msg, vkm.Up):
+		m.moveCursor(-1)
+	case key.Matches(msg, vkm.PageDown):
+		m.moveCursor(m.visibleRows())
+	case key.Matches(msg, vkm.PageUp):
+		m.moveCursor(-m.visibleRows())
+	case key.Matches(msg, vkm.HalfPageDown):
+		m.moveCursor(m.visibleRows() / 2)
+	case key.Matches(msg, vkm.HalfPageUp):
+		m.moveCursor(-m.visibleRows() / 2)
+	default:
+		return false
+	}
+	return true
+}
+
+// printSelection keeps the plain text of the lines selected, to be printed
+// once the pager quits.
+func (m *model) printSelection() {
+	b := m.buf()
+	first, last := m.selection()
+	m.output = make([]string, 0, last-first+1)
+	for i := first; i <= last && i < b.doc.Len(); i++ {
+		m.output = append(m.output, b.doc.Plain(i))
+	}
+}
+
+// selectionStatus counts the lines selected.
+func (m *model) selectionStatus() string {
+	if !m.selecting {
+		return ""
+	}
+	first, last := m.selection()
+	if first == last {
+		return "1 line selected"
+	}
+	return fmt.Sprintf("%d lines selected", last-first+1)
+}
@@ -0,0 +1,118 @@
+package pager
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// pollInterval is how often a followed file is checked for new data.
+const pollInterval = 250 * time.Millisecond
+
+// maxBatch is the maximum number of lines appended to the view at once.
+const maxBatch = 4096
+
+// streamMsg carries the lines read from a stream since the last message.
+type streamMsg struct {
+	lines []string
+	done  bool
+	err   error
+}
+
+// stream reads lines in the background and hands them over in batches.
+type stream struct {
+	lines  chan string
+	err    error
+	strike overstrike
+}
+
+// newStream starts reading lines from r. When follow is set, reaching the
+// end of the input waits for more data instead of closing the stream.
+func newStream(r io.Reader, follow bool, strike overstrike) *stream {
+	s := &stream{lines: make(chan string, maxBatch), strike: strike}
+	go s.read(r, follow)
+	return s
+}
+
+func (s *stream) read(r io.Reader, follow bool) {
+	defer close(s.lines)
+
+	reader := bufio.NewReader(r)
+	var (
+		partial string
+		offset  int64
+	)
+	for {
+		line, err := reader.ReadString('\n')
+		offset += int64(len(line))
+		partial += line
+		if err == nil {
+			s.lines <- s.sanitize(partial)
+			partial = ""
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			s.err = fmt.Errorf("unable to read input: %w", err)
+			return
+		}
+		if !follow {
+			if partial != "" {
+				s.lines <- s.sanitize(partial)
+			}
+			return
+		}
+
+		// Start over when the file was truncated, e.g. by log rotation.
+		if f, ok := r.(*os.File); ok {
+			if info, err := f.Stat(); err == nil && info.Size() < offset {
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					s.err = fmt.Errorf("unable to follow file: %w", err)
+					return
+				}
+				reader.Reset(f)
+				offset = 0
+				partial = ""
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// wait returns a command waiting for the next batch of lines.
+func (s *stream) wait() tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-s.lines
+		if !ok {
+			return streamMsg{done: true, err: s.err}
+		}
+		msg := streamMsg{lines: []string{line}}
+		for len(msg.lines) < maxBatch {
+			select {
+			case line, ok := <-s.lines:
+				if !ok {
+					msg.done = true
+					msg.err = s.err
+					return msg
+				}
+				msg.lines = append(msg.lines, line)
+			default:
+				return msg
+			}
+		}
+		return msg
+	}
+}
+
+// sanitize removes the line ending from a line, and renders its overstruck
+// text.
+func (s *stream) sanitize(line string) string {
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return s.strike.render(line)
+}
@@ -0,0 +1,227 @@
+package pager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Only the lines in view are read, wrapped and rendered. The position in the
+// document is the first line shown along with the first of its rows, so that
+// nothing needs to know how many rows the lines above take.
+
+// separator sets the line numbers apart from the text.
+const separator = " │ "
+
+// gutterWidth returns the width of the line numbers, wide enough for the last
+// line.
+func (m *model) gutterWidth() int {
+	if !m.showLineNumbers {
+		return 0
+	}
+	return max(4, len(strconv.Itoa(m.buf().doc.Len()))) + ansi.StringWidth(separator)
+}
+
+// visibleRows returns how many rows fit in the view.
+func (m *model) visibleRows() int {
+	return max(m.viewport.Height()-m.viewport.Style.GetVerticalFrameSize(), 0)
+}
+
+// styledLine returns a line with its colors.
+func (m *model) styledLine(i int) string {
+	b := m.buf()
+	if b.styled != nil {
+		return b.styled[i]
+	}
+	line := b.doc.Line(i)
+	if b.highlighter != nil {
+		return b.highlighter.highlight([]string{line})[0]
+	}
+	return line
+}
+
+// rowsOf returns how many rows a line takes once wrapped.
+func (m *model) rowsOf(i int) int {
+	if !m.softWrap || m.textWidth <= 0 {
+		return 1
+	}
+	return max(1, (ansi.StringWidth(m.buf().doc.Line(i))+m.textWidth-1)/m.textWidth)
+}
+
+// renderLine renders the rows of a line, each with the line number gutter.
+func (m *model) renderLine(i int) []string {
+	line := m.styledLine(i)
+	if m.selected(i) {
+		line = m.selectionStyle.Render(m.buf().doc.Plain(i))
+	}
+	line = m.highlightMatches(i, line)
+	digits := m.gutterWidth() - ansi.StringWidth(separator)
+	number := ""
+	if m.showLineNumbers {
+		number = m.lineNumberStyle.Render(fmt.Sprintf("%*d", digits, i+1) + separator)
+	}
+	if !m.softWrap || m.textWidth <= 0 {
+		left := m.buf().left
+		return []string{number + ansi.Cut(line, left, left+max(m.textWidth, 0))}
+	}
+
+	var rows []string
+	width := ansi.StringWidth(line)
+	for idx := 0; idx == 0 || idx < width; idx += m.textWidth {
+		rows = append(rows, number+ansi.Cut(line, idx, idx+m.textWidth))
+		if m.showLineNumbers {
+			number = m.lineNumberStyle.Render(strings.Repeat(" ", digits) + separator)
+		}
+	}
+	return rows
+}
+
+// windowView renders the rows in view, filling the rest of the view when the
+// document is too short.
+func (m *model) windowView() string {
+	b := m.buf()
+	visible := m.visibleRows()
+	rows := make([]string, 0, visible)
+	for i := b.top; i < b.doc.Len() && len(rows) < visible; i++ {
+		lineRows := m.renderLine(i)
+		if i == b.top {
+			lineRows = lineRows[min(b.topRow, len(lineRows)-1):]
+		}
+		rows = append(rows, lineRows...)
+	}
+	rows = rows[:min(len(rows), visible)]
+	if m.showLineNumbers {
+		digits := m.gutterWidth() - ansi.StringWidth(separator)
+		filler := m.lineNumberStyle.Render(fmt.Sprintf("%*s", digits, "~") + separator)
+		for len(rows) < visible {
+			rows = append(rows, filler)
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// bottom returns the position showing the last rows of the document.
+func (m *model) bottom() (int, int) {
+	rows := m.visibleRows()
+	for line := m.buf().doc.Len() - 1; line >= 0; line-- {
+		n := m.rowsOf(line)
+		if n >= rows {
+			return line, n - rows
+		}
+		rows -= n
+	}
+	return 0, 0
+}
+
+// before reports whether a position comes before another one.
+func before(line, row, otherLine, otherRow int) bool {
+	return line < otherLine || (line == otherLine && row < otherRow)
+}
+
+// atBottom reports whether the last row of the document is in view.
+func (m *model) atBottom() bool {
+	b := m.buf()
+	line, row := m.bottom()
+	return !before(b.top, b.topRow, line, row)
+}
+
+// scrollDown moves the view n rows down, stopping at the bottom.
+func (m *model) scrollDown(n int) {
+	b := m.buf()
+	line, row := m.bottom()
+	for ; n > 0 && before(b.top, b.topRow, line, row); n-- {
+		b.topRow++
+		if b.topRow >= m.rowsOf(b.top) {
+			b.top++
+			b.topRow = 0
+		}
+	}
+}
+
+// scrollUp moves the view n rows up, stopping at the top.
+func (m *model) scrollUp(n int) {
+	b := m.buf()
+	for ; n > 0 && (b.top > 0 || b.topRow > 0); n-- {
+		if b.topRow > 0 {
+			b.topRow--
+			continue
+		}
+		b.top--
+		b.topRow = m.rowsOf(b.top) - 1
+	}
+}
+
+func (m *model) gotoTop() {
+	b := m.buf()
+	b.top, b.topRow = 0, 0
+}
+
+func (m *model) gotoBottom() {
+	b := m.buf()
+	b.top, b.topRow = m.bottom()
+}
+
+// scrollTo shows a row of a line at the top of the view, as far as the end of
+// the document allows.
+func (m *model) scrollTo(line, row int) {
+	b := m.buf()
+	b.top, b.topRow = max(line, 0), max(row, 0)
+	b.cursor = max(min(line, b.doc.Len()-1), 0)
+	m.clamp()
+}
+
+// clamp keeps the view within the document, for instance after it was
+// resized.
+func (m *model) clamp() {
+	b := m.buf()
+	if b.top >= b.doc.Len() {
+		b.top, b.topRow = b.doc.Len(), 0
+	}
+	if b.top < b.doc.Len() {
+		b.topRow = min(b.topRow, m.rowsOf(b.top)-1)
+	}
+	if line, row := m.bottom(); before(line, row, b.top, b.topRow) {
+		b.top, b.topRow = line, row
+	}
+}
+
+// scrollLeft moves the view n columns left, when lines aren't wrapped.
+func (m *model) scrollLeft(n int) {
+	b := m.buf()
+	b.left = max(b.left-n, 0)
+}
+
+// scrollRight moves the view n columns right, as far as the widest line in
+// view allows.
+func (m *model) scrollRight(n int) {
+	b := m.buf()
+	b.left = max(min(b.left+n, m.widestInView()-m.textWidth), b.left)
+}
+
+// widestInView returns the width of the widest line in view.
+func (m *model) widestInView() int {
+	b := m.buf()
+	widest := 0
+	for i := b.top; i < b.doc.Len() && i < b.top+m.visibleRows(); i++ {
+		widest = max(widest, ansi.StringWidth(b.doc.Line(i)))
+	}
+	return widest
+}
+
+// inView reports whether a row of a line is shown.
+func (m *model) inView(line, row int) bool {
+	b := m.buf()
+	if before(line, row, b.top, b.topRow) {
+		return false
+	}
+	rows := -b.topRow
+	for i := b.top; i < line; i++ {
+		rows += m.rowsOf(i)
+		if rows >= m.visibleRows() {
+			return false
+		}
+	}
+	return rows+row < m.visibleRows()
+}
@@ -0,0 +1,154 @@
+package table
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// shown returns the indexes of the columns which aren't hidden, in the order
+// they are displayed.
+func (m model) shown() []int {
+	shown := make([]int, 0, len(m.order))
+	for _, c := range m.order {
+		if !m.hidden[c] {
+			shown = append(shown, c)
+		}
+	}
+	return shown
+}
+
+// updateChooser handles the keys while the column chooser is open. Every
+// change is applied to the table right away.
+func (m model) updateChooser(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	km := m.keymap
+	tkm := m.table.KeyMap
+	switch {
+	case key.Matches(msg, km.Abort):
+		m.quitting = true
+		return m, tea.Interrupt
+	case key.Matches(msg, km.CloseChooser):
+		m.choosing = false
+	case key.Matches(msg, tkm.LineUp):
+		m.chooserCursor = max(m.chooserCursor-1, 0)
+	case key.Matches(msg, tkm.LineDown):
+		m.chooserCursor = min(m.chooserCursor+1, len(m.order)-1)
+	case key.Matches(msg, km.ToggleColumn):
+		c := m.order[m.chooserCursor]
+		// Keep at least one column around.
+		if !m.hidden[c] && len(m.shown()) == 1 {
+			break
+		}
+		m.hidden[c] = !m.hidden[c]
+		if m.hidden[c] && m.col == c {
+			m.col = m.shown()[0]
+		}
+	case key.Matches(msg, km.MoveColumnUp):
+		if i := m.chooserCursor; i > 0 {
+			m.order[i-1], m.order[i] = m.order[i], m.order[i-1]
+			m.chooserCursor--
+		}
+	case key.Matches(msg, km.MoveColumnDown):
+		if i := m.chooserCursor; i < len(m.order)-1 {
+			m.order[i+1], m.order[i] = m.order[i], m.order[i+1]
+			m.chooserCursor++
+		}
+	}
+	m.layout()
+	return m, nil
+}
+
+// chooserView renders the list of columns with their visibility.
+func (m model) chooserView() string {
+	var b strings.Builder
+	for i, c := range m.order {
+		check := "[x] "
+		if m.hidden[c] {
+			check = "[ ] "
+		}
+		line := check + m.header[c]
+		if i == m.chooserCursor {
+			line = m.selectedStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	km := m.keymap
+	b.WriteString("\n" + m.help.ShortHelpView([]key.Binding{km.ToggleColumn, km.MoveColumnUp, km.MoveColumnDown, km.CloseChooser}))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(0, 1).
+		Render(b.String())
+}
+
+// columnsState is the layout of the columns saved by --columns-state.
+type columnsState struct {
+	Columns []columnState `json:"columns"`
+}
+
+type columnState struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// loadColumnsState restores the order and visibility of the columns. Columns
+// missing from the state keep their place at the end, and the state of
+// columns which no longer exist is dropped.
+func loadColumnsState(path string, header []string) ([]int, map[int]bool, error) {
+	order := make([]int, 0, len(header))
+	hidden := map[int]bool{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("unable to read columns state: %w", err)
+	}
+
+	if err == nil {
+		var state columnsState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, nil, fmt.Errorf("invalid columns state: %w", err)
+		}
+		for _, column := range state.Columns {
+			i := slices.Index(header, column.Name)
+			if i < 0 || slices.Contains(order, i) {
+				continue
+			}
+			order = append(order, i)
+			hidden[i] = column.Hidden
+		}
+	}
+	for i := range header {
+		if !slices.Contains(order, i) {
+			order = append(order, i)
+		}
+	}
+	// Keep at least one column around.
+	if len(order) > 0 && !slices.ContainsFunc(order, func(i int) bool { return !hidden[i] }) {
+		hidden[order[0]] = false
+	}
+	return order, hidden, nil
+}
+
+// saveColumnsState writes the order and visibility of the columns.
+func (m model) saveColumnsState(path string) error {
+	var state columnsState
+	for _, c := range m.order {
+		state.Columns = append(state.Columns, columnState{Name: m.header[c], Hidden: m.hidden[c]})
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to save columns state: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("unable to save columns state: %w", err)
+	}
+	return nil
+}
@@ -1,19 +1,23 @@
 package table
 
 import (
-	"encoding/csv"
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"slices"
+	"strings"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/gum/v2/internal/stdin"
 	"charm.land/gum/v2/internal/timeout"
-	"charm.land/gum/v2/internal/tty"
 	"charm.land/gum/v2/style"
 	"charm.land/lipgloss/v2"
-	ltable "charm.land/lipgloss/v2/table"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
@@ -36,121 +40,245 @@ func (o Options) Run() error {
 	}
 	defer input.Close() //nolint: errcheck
 
-	transformer := unicode.BOMOverride(encoding.Nop.NewDecoder())
-	reader := csv.NewReader(transform.NewReader(input, transformer))
-	reader.LazyQuotes = o.LazyQuotes
-	reader.FieldsPerRecord = o.FieldsPerRecord
-	separatorRunes := []rune(o.Separator)
-	if len(separatorRunes) != 1 {
+	if len([]rune(o.Separator)) != 1 {
 		return fmt.Errorf("separator must be single character")
 	}
-	reader.Comma = separatorRunes[0]
 
-	writer := csv.NewWriter(os.Stdout)
-	writer.Comma = separatorRunes[0]
+	// Rows are only read in the background when the table is interactive,
+	// printing, sorting and editing need all of them first.
+	printing := o.Print || o.Output != outputTable
+	switch {
+	case o.Stream && o.Editable:
+		return errors.New("--editable can't be combined with --stream")
+	case o.Stream && printing:
+		return errors.New("--print and --output can't be combined with --stream")
+	case o.Stream && len(o.Sort) > 0:
+		return errors.New("--sort can't be combined with --stream")
+	}
+	sample := -1
+	if o.Stream {
+		sample = o.Sample
+	}
+
+	transformer := unicode.BOMOverride(encoding.Nop.NewDecoder())
+	reader := bufio.NewReader(transform.NewReader(input, transformer))
+	if o.InputFormat == formatAuto {
+		o.InputFormat = sniffFormat(reader, o.Separator)
+	}
+	// Editing writes JSON back from the values read, rather than the cells.
+	var values *jsonValues
+	if o.Editable {
+		values = &jsonValues{}
+	}
+	columnNames, next, err := o.openTable(reader, sample, values)
+	if err != nil {
+		return err
+	}
+
+	q, err := o.query(columnNames)
+	if err != nil {
+		return err
+	}
+	if o.Editable && !q.empty() {
+		return errors.New("--editable can't be combined with --select, --where, --max-rows or --sort")
+	}
+	// Rows are normalized and filtered as they are read, then sorted once
+	// all of them are.
+	next = q.filter(next, len(columnNames))
+	if len(o.Sort) == 0 {
+		next = q.rows(next)
+	}
+	columnNames = q.header(columnNames)
 
-	var columnNames []string
-	var err error
-	// If no columns are provided we'll use the first row of the CSV as the
-	// column names.
-	if len(o.Columns) <= 0 {
-		columnNames, err = reader.Read()
+	// Read the whole data, or only a sample of it when streaming.
+	var data [][]string
+	loading := true
+	for sample < 0 || len(data) < sample {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			loading = false
+			break
+		}
 		if err != nil {
-			return fmt.Errorf("unable to parse columns")
+			return err
 		}
-	} else {
-		columnNames = o.Columns
+		data = append(data, row)
 	}
+	data = q.apply(data)
 
-	data, err := reader.ReadAll()
+	formats, err := o.columnFormats(columnNames, data)
 	if err != nil {
-		return fmt.Errorf("invalid data provided")
+		return err
 	}
-	columns := make([]table.Column, 0, len(columnNames))
+	display := o.formatData(data, formats)
 
+	defaultStyles := table.DefaultStyles()
+	top, right, bottom, left := style.ParsePadding(o.Padding)
+
+	styles := table.Styles{
+		Cell:     defaultStyles.Cell.Inherit(o.CellStyle.ToLipgloss()),
+		Header:   defaultStyles.Header.Inherit(o.HeaderStyle.ToLipgloss()),
+		Selected: o.SelectedStyle.ToLipgloss(),
+	}
+
+	styler, err := o.styler(columnNames)
+	if err != nil {
+		return err
+	}
+	names, returned, err := o.returnColumns(columnNames)
+	if err != nil {
+		return err
+	}
+
+	footerFuncs, err := o.footerColumns(columnNames)
+	if err != nil {
+		return err
+	}
+	totals := newTotals(footerFuncs)
+	totals.add(data...)
+	footer := totals.row(formats, o.Thousands)
+
+	if printing {
+		return o.print(columnNames, data, display, footer, formats, styles, styler)
+	}
+
+	// Column widths are computed from the data read so far, which is only a
+	// sample when streaming.
+	columns := make([]table.Column, 0, len(columnNames)+1)
+	widths := make([]int, 0, len(columnNames))
 	for i, title := range columnNames {
 		width := lipgloss.Width(title)
 		if len(o.Widths) > i {
 			width = o.Widths[i]
+		} else {
+			for _, row := range display {
+				width = max(width, lipgloss.Width(row[i]))
+			}
+			if footer != nil {
+				width = max(width, lipgloss.Width(footer[i]))
+			}
+			if formats[i].maxWidth > 0 {
+				width = min(width, formats[i].maxWidth)
+			}
 		}
+		widths = append(widths, width)
 		columns = append(columns, table.Column{
-			Title: title,
+			Title: alignCell(title, width, formats[i].align),
 			Width: width,
 		})
 	}
 
-	defaultStyles := table.DefaultStyles()
-	top, right, bottom, left := style.ParsePadding(o.Padding)
+	rows := make([]table.Row, 0, len(data))
+	for _, row := range data {
+		rows = append(rows, table.Row(row))
+	}
 
-	styles := table.Styles{
-		Cell:     defaultStyles.Cell.Inherit(o.CellStyle.ToLipgloss()),
-		Header:   defaultStyles.Header.Inherit(o.HeaderStyle.ToLipgloss()),
-		Selected: o.SelectedStyle.ToLipgloss(),
+	if o.NoLimit {
+		o.Limit = math.MaxInt
 	}
 
-	rows := make([]table.Row, 0, len(data))
-	for row := range data {
-		if len(data[row]) > len(columns) {
-			return fmt.Errorf("invalid number of columns")
-		}
+	km := defaultKeymap()
+	readonly, validators, err := o.editRules(columnNames)
+	if err != nil {
+		return err
+	}
+	if o.Editable {
+		// Rows can't be picked while editing, the whole data is written back
+		// instead.
+		o.Limit = 1
+		km.Select.SetEnabled(false)
+		km.NextCell.SetEnabled(true)
+		km.PrevCell.SetEnabled(true)
+		km.Edit.SetEnabled(true)
+		km.Save.SetEnabled(true)
+	}
 
-		// fixes the data in case we have more columns than rows:
-		for len(data[row]) < len(columns) {
-			data[row] = append(data[row], "")
-		}
+	if o.Limit > 1 {
+		km.Toggle.SetEnabled(true)
+		km.ToggleAll.SetEnabled(true)
+	}
 
-		for i, col := range data[row] {
-			if len(o.Widths) == 0 {
-				width := lipgloss.Width(col)
-				if width > columns[i].Width {
-					columns[i].Width = width
-				}
-			}
+	order := make([]int, 0, len(columnNames))
+	for i := range columnNames {
+		order = append(order, i)
+	}
+	hidden := map[int]bool{}
+	if o.ColumnsState != "" {
+		order, hidden, err = loadColumnsState(o.ColumnsState, columnNames)
+		if err != nil {
+			return err
 		}
-
-		rows = append(rows, table.Row(data[row]))
 	}
 
-	if o.Print {
-		table := ltable.New().
-			Headers(columnNames...).
-			Rows(data...).
-			BorderStyle(o.BorderStyle.ToLipgloss()).
-			Border(style.Border[o.Border]).
-			StyleFunc(func(row, _ int) lipgloss.Style {
-				if row == 0 {
-					return styles.Header
-				}
-				return styles.Cell
-			})
+	mins, weights := o.widthLimits(formats, widths)
+	m := model{
+		rows:             rows,
+		columns:          columns,
+		header:           columnNames,
+		natural:          widths,
+		mins:             mins,
+		weights:          weights,
+		fit:              o.Fit,
+		freeze:           o.Freeze,
+		order:            order,
+		hidden:           hidden,
+		cellFrame:        styles.Cell.GetHorizontalFrameSize(),
+		showHelp:         o.ShowHelp,
+		hideCount:        o.HideCount,
+		help:             help.New(),
+		keymap:           km,
+		padding:          []int{top, right, bottom, left},
+		formats:          formats,
+		widths:           slices.Clone(widths),
+		thousands:        o.Thousands,
+		styler:           styler,
+		cellStyle:        styles.Cell,
+		maxRowHeight:     o.MaxRowHeight,
+		continuation:     o.Continuation,
+		selectedStyle:    styles.Selected,
+		footerFuncs:      footerFuncs,
+		totals:           totals,
+		footer:           footer,
+		footerStyle:      o.FooterStyle.ToLipgloss(),
+		detail:           o.Detail,
+		detailPosition:   o.DetailPosition,
+		detailPane:       viewport.New(),
+		detailKeyStyle:   o.DetailKeyStyle.ToLipgloss(),
+		loading:          o.Stream && loading,
+		next:             next,
+		editable:         o.Editable,
+		input:            newCellInput(),
+		edited:           map[[2]int]bool{},
+		readonly:         readonly,
+		validators:       validators,
+		activeStyle:      o.ActiveCellStyle.ToLipgloss(),
+		editedStyle:      o.EditedStyle.ToLipgloss(),
+		errorStyle:       lipgloss.NewStyle().Foreground(lipgloss.Color("9")),
+		limit:            o.Limit,
+		marks:            map[int]int{},
+		selectedPrefix:   o.SelectedPrefix,
+		unselectedPrefix: o.UnselectedPrefix,
+	}
 
-		tty.Println(table.Render())
-		return nil
+	if o.Limit > 1 {
+		// Reserve a gutter column to show which rows are selected.
+		m.gutter = max(lipgloss.Width(o.SelectedPrefix), lipgloss.Width(o.UnselectedPrefix))
 	}
 
 	opts := []table.Option{
-		table.WithColumns(columns),
 		table.WithFocused(true),
-		table.WithRows(rows),
 		table.WithStyles(styles),
 	}
 	if o.Height > 0 {
 		opts = append(opts, table.WithHeight(o.Height-top-bottom))
 	}
 
-	table := table.New(opts...)
+	m.table = table.New(opts...)
+	m.layout()
 
 	ctx, cancel := timeout.Context(o.Timeout)
 	defer cancel()
 
-	m := model{
-		table:     table,
-		showHelp:  o.ShowHelp,
-		hideCount: o.HideCount,
-		help:      help.New(),
-		keymap:    defaultKeymap(),
-		padding:   []int{top, right, bottom, left},
-	}
 	tm, err := tea.NewProgram(
 		m,
 		tea.WithOutput(os.Stderr),
@@ -165,17 +293,51 @@ func (o Options) Run() error {
 	}
 
 	m = tm.(model)
-	if o.ReturnColumn > 0 && o.ReturnColumn <= len(m.selected) {
-		if err = writer.Write([]string{m.selected[o.ReturnColumn-1]}); err != nil {
-			return fmt.Errorf("failed to write col %d of selected row: %w", o.ReturnColumn, err)
+	if o.ColumnsState != "" {
+		if err := m.saveColumnsState(o.ColumnsState); err != nil {
+			return err
 		}
-	} else {
-		if err = writer.Write([]string(m.selected)); err != nil {
-			return fmt.Errorf("failed to write selected row: %w", err)
+	}
+	if m.err != nil {
+		return m.err
+	}
+
+	if o.Editable {
+		if !m.submitted {
+			return errors.New("nothing saved")
 		}
+		out, err := o.encodeData(columnNames, m.rows, values, m.edited)
+		if err != nil {
+			return fmt.Errorf("failed to write data: %w", err)
+		}
+		fmt.Println(strings.TrimSuffix(out, "\n"))
+		return nil
 	}
 
-	writer.Flush()
+	if !m.submitted {
+		return errors.New("nothing selected")
+	}
+	selection := []table.Row{m.selected}
+	if o.Limit > 1 {
+		selection = m.selection(o.Ordered)
+	}
+
+	out, err := o.formatSelection(names, selection, returned)
+	if err != nil {
+		return fmt.Errorf("failed to write selected row: %w", err)
+	}
+	fmt.Println(out)
 
 	return nil
 }
+
+// normalizeRow fills in missing cells so that the row has one per column.
+func normalizeRow(row []string, columns int) ([]string, error) {
+	if len(row) > columns {
+		return nil, fmt.Errorf("invalid number of columns")
+	}
+	for len(row) < columns {
+		row = append(row, "")
+	}
+	return row, nil
+}
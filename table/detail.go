@@ -0,0 +1,111 @@
+package table
+
+import (
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Positions of the detail pane.
+const (
+	detailRight  = "right"
+	detailBottom = "bottom"
+)
+
+const (
+	// detailHeight is the height of the detail pane below the table.
+	detailHeight = 8
+	// detailWidth is the width of the detail pane next to the table, as long
+	// as the size of the terminal is unknown.
+	detailWidth = 40
+)
+
+// detailSize returns the width taken by the detail pane when it is displayed
+// next to the table.
+func (m model) detailSize() int {
+	if !m.detail || m.detailPosition != detailRight {
+		return 0
+	}
+	if m.width <= 0 {
+		return detailWidth
+	}
+	return max(m.width/3, 24)
+}
+
+// resizeDetail fits the detail pane next to or below the table.
+func (m *model) resizeDetail() {
+	frame := m.detailStyle().GetHorizontalFrameSize()
+	if m.detailPosition == detailRight {
+		m.detailPane.SetWidth(max(m.detailSize()-frame, 1))
+		// The pane spans the header and the footer of the table too.
+		height := lipgloss.Height(m.table.View())
+		if m.footer != nil {
+			height++
+		}
+		m.detailPane.SetHeight(height)
+	} else {
+		m.detailPane.SetWidth(max(m.table.Width()-frame, 1))
+		m.detailPane.SetHeight(detailHeight)
+	}
+	m.detailRow = -1
+}
+
+// refreshDetail shows the fields of the highlighted row in the detail pane,
+// scrolling back to the top when moving to another row.
+func (m *model) refreshDetail() {
+	if !m.detail {
+		return
+	}
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		m.detailPane.SetContent("")
+		return
+	}
+
+	width := m.detailPane.Width()
+	keyWidth := 0
+	for _, name := range m.header {
+		keyWidth = max(keyWidth, ansi.StringWidth(name)+1)
+	}
+	keyWidth = min(keyWidth, width/2)
+	indent := strings.Repeat(" ", keyWidth+1)
+
+	var b strings.Builder
+	for i, name := range m.header {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		name = ansi.Truncate(name+":", keyWidth, "…")
+		b.WriteString(m.detailKeyStyle.Render(pad(name, keyWidth)) + " ")
+
+		value := m.formats[i].value(m.rows[m.cursor][i], m.thousands)
+		value = ansi.Wrap(value, max(width-keyWidth-1, 1), "")
+		b.WriteString(strings.ReplaceAll(value, "\n", "\n"+indent))
+	}
+	m.detailPane.SetContent(b.String())
+
+	if m.detailRow != m.cursor {
+		m.detailRow = m.cursor
+		m.detailPane.GotoTop()
+	}
+}
+
+func (m model) detailStyle() lipgloss.Style {
+	s := lipgloss.NewStyle().BorderForeground(lipgloss.Color("240"))
+	if m.detailPosition == detailRight {
+		return s.Border(lipgloss.NormalBorder(), false, false, false, true).PaddingLeft(1)
+	}
+	return s.Border(lipgloss.NormalBorder(), true, false, false, false)
+}
+
+// detailView places the detail pane next to or below the table.
+func (m model) detailView(table string) string {
+	if !m.detail {
+		return table
+	}
+	pane := m.detailStyle().Render(m.detailPane.View())
+	if m.detailPosition == detailRight {
+		return lipgloss.JoinHorizontal(lipgloss.Top, table, pane)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, table, pane)
+}
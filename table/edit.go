@@ -0,0 +1,347 @@
+package table
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+)
+
+// editRules parses the --readonly and --validate flags.
+func (o Options) editRules(header []string) (map[int]bool, map[int]*regexp.Regexp, error) {
+	readonly := make(map[int]bool, len(o.Readonly))
+	for _, ref := range o.Readonly {
+		i, err := columnIndex(header, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		readonly[i] = true
+	}
+
+	patterns, err := parseColumnFlags(header, o.Validate)
+	if err != nil {
+		return nil, nil, err
+	}
+	validators := make(map[int]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid validation for column %q: %w", header[i], err)
+		}
+		validators[i] = re
+	}
+	return readonly, validators, nil
+}
+
+func newCellInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = ""
+	return input
+}
+
+// moveCell moves the active cell horizontally. When wrap is set, moving past
+// the last column continues on the next row and vice versa.
+func (m *model) moveCell(delta int, wrap bool) {
+	shown := m.shown()
+	if len(shown) == 0 {
+		return
+	}
+	pos := slices.Index(shown, m.col) + delta
+	switch {
+	case pos >= len(shown) && wrap && m.cursor < len(m.rows)-1:
+		pos = 0
+		m.cursor++
+	case pos < 0 && wrap && m.cursor > 0:
+		pos = len(shown) - 1
+		m.cursor--
+	}
+	m.col = shown[max(min(pos, len(shown)-1), 0)]
+	m.layout()
+}
+
+// startEditing opens the input on the active cell.
+func (m *model) startEditing() tea.Cmd {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return nil
+	}
+	if m.readonly[m.col] {
+		m.editErr = "column is read-only"
+		return nil
+	}
+
+	m.editing = true
+	m.input.SetWidth(max(m.widths[m.col]-1, 1))
+	m.input.SetValue(m.rows[m.cursor][m.col])
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// stopEditing saves the value of the input in the active cell, as long as it
+// is valid.
+func (m *model) stopEditing() bool {
+	value := m.input.Value()
+	if re := m.validators[m.col]; re != nil && !re.MatchString(value) {
+		m.editErr = fmt.Sprintf("%q does not match %s", value, re)
+		return false
+	}
+
+	if m.rows[m.cursor][m.col] != value {
+		m.rows[m.cursor][m.col] = value
+		m.edited[[2]int{m.cursor, m.col}] = true
+		m.updateFooter()
+	}
+	m.editErr = ""
+	m.editing = false
+	m.input.Blur()
+	return true
+}
+
+func (m model) updateEditing(msg tea.KeyPressMsg) (tea.Model, tea.Cmd) {
+	km := m.keymap
+	var cmd tea.Cmd
+	switch {
+	case key.Matches(msg, km.Abort):
+		m.quitting = true
+		return m, tea.Interrupt
+	case key.Matches(msg, km.CancelEdit):
+		m.editing = false
+		m.editErr = ""
+		m.input.Blur()
+	case key.Matches(msg, km.ConfirmEdit):
+		m.stopEditing()
+	case key.Matches(msg, km.NextCell, km.PrevCell):
+		if !m.stopEditing() {
+			break
+		}
+		delta := 1
+		if key.Matches(msg, km.PrevCell) {
+			delta = -1
+		}
+		// Skip over read-only cells.
+		for range len(m.formats) {
+			m.moveCell(delta, true)
+			if !m.readonly[m.col] {
+				break
+			}
+		}
+		cmd = m.startEditing()
+	default:
+		m.input, cmd = m.input.Update(msg)
+	}
+	m.sync()
+	return m, cmd
+}
+
+// encodeData writes the whole (edited) data set back in the input format.
+// JSON is written from the values it was read from, see encodeJSON.
+func (o Options) encodeData(header []string, rows []table.Row, values *jsonValues, edited map[[2]int]bool) (string, error) {
+	data := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, row)
+	}
+
+	switch o.InputFormat {
+	case formatJSON, formatJSONL:
+		return encodeJSON(header, data, values, edited, o.InputFormat == formatJSONL)
+	case formatMarkdown:
+		return renderMarkdown(header, data), nil
+	}
+
+	// Only write the header back if it was part of the input.
+	if len(o.Columns) == 0 {
+		data = append([][]string{header}, data...)
+	}
+	if o.InputFormat == formatTSV {
+		return renderTSV(data[0], data[1:]), nil
+	}
+	return renderCSV(data[0], data[1:], []rune(o.Separator)[0])
+}
+
+// encodeJSON writes back the JSON values the rows were read from, with only
+// the edited cells replaced. Keys that aren't shown as columns, the types of
+// the values and the nesting of the objects are all kept.
+func encodeJSON(header []string, data [][]string, values *jsonValues, edited map[[2]int]bool, lines bool) (string, error) {
+	if values == nil || len(values.rows) != len(data) {
+		return "", fmt.Errorf("unable to encode json: the rows don't match the input")
+	}
+
+	cells := slices.SortedFunc(maps.Keys(edited), func(a, b [2]int) int {
+		return cmp.Or(a[0]-b[0], a[1]-b[1])
+	})
+	rows := slices.Clone(values.rows)
+	for _, cell := range cells {
+		r, c := cell[0], cell[1]
+		// Objects are walked by key, arrays of cells by position.
+		path := []string{strconv.Itoa(c)}
+		if isJSONObject(rows[r]) {
+			path = strings.Split(header[c], ".")
+		}
+		raw, err := setJSON(rows[r], path, data[r][c])
+		if err != nil {
+			return "", fmt.Errorf("unable to encode json: %w", err)
+		}
+		rows[r] = raw
+	}
+	if values.header != nil {
+		rows = append([]json.RawMessage{values.header}, rows...)
+	}
+
+	var b bytes.Buffer
+	if lines {
+		for _, raw := range rows {
+			if err := json.Compact(&b, raw); err != nil {
+				return "", fmt.Errorf("unable to encode json: %w", err)
+			}
+			b.WriteString("\n")
+		}
+		return b.String(), nil
+	}
+
+	b.WriteString("[")
+	for i, raw := range rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.Write(raw)
+	}
+	b.WriteString("]")
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "", "  "); err != nil {
+		return "", fmt.Errorf("unable to encode json: %w", err)
+	}
+	return out.String(), nil
+}
+
+// setJSON replaces the value found at the path of keys, or of positions in an
+// array, with the cell. Keys holding dots are matched as a whole, the way
+// flattenJSON names the columns. Missing keys are added.
+func setJSON(raw json.RawMessage, path []string, cell string) (json.RawMessage, error) {
+	if len(path) == 0 {
+		return typedJSON(raw, cell), nil
+	}
+
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("invalid position %q", path[0])
+		}
+		for len(items) <= i {
+			items = append(items, json.RawMessage("null"))
+		}
+		if items[i], err = setJSON(items[i], path[1:], cell); err != nil {
+			return nil, err
+		}
+		return json.Marshal(items) //nolint:wrapcheck
+	}
+
+	// Anything but an object is replaced by one holding the cell.
+	var keys []string
+	var vals []json.RawMessage
+	if isJSONObject(trimmed) {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := dec.Token(); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			key, _ := tok.(string)
+			keys = append(keys, key)
+			vals = append(vals, value)
+		}
+	}
+
+	found := false
+	for n := len(path); n > 0 && !found; n-- {
+		if i := slices.Index(keys, strings.Join(path[:n], ".")); i >= 0 {
+			value, err := setJSON(vals[i], path[n:], cell)
+			if err != nil {
+				return nil, err
+			}
+			vals[i], found = value, true
+		}
+	}
+	if !found {
+		value, err := setJSON(nil, path[1:], cell)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, path[0])
+		vals = append(vals, value)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		k, _ := json.Marshal(key)
+		b.Write(k)
+		b.WriteString(":")
+		b.Write(vals[i])
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+// typedJSON encodes an edited cell as the same type as the value it replaces,
+// as long as the text still reads as one, and as a string otherwise. Numbers
+// and booleans that were cleared become null.
+func typedJSON(orig json.RawMessage, cell string) json.RawMessage {
+	orig = bytes.TrimSpace(orig)
+	trimmed := strings.TrimSpace(cell)
+	var kind byte
+	if len(orig) > 0 {
+		kind = orig[0]
+	}
+
+	switch {
+	case kind == 'n' && trimmed == "":
+		return orig
+	case kind == 't', kind == 'f', kind == '-', kind >= '0' && kind <= '9':
+		if trimmed == "" {
+			return json.RawMessage("null")
+		}
+		if isJSONLiteral(trimmed, kind) {
+			return json.RawMessage(trimmed)
+		}
+	case kind == '[', kind == '{':
+		if trimmed != "" && trimmed[0] == kind && json.Valid([]byte(trimmed)) {
+			return json.RawMessage(trimmed)
+		}
+	}
+	v, _ := json.Marshal(cell)
+	return v
+}
+
+// isJSONLiteral reports whether the text is a boolean when kind is the first
+// byte of one, or a number otherwise.
+func isJSONLiteral(text string, kind byte) bool {
+	if kind == 't' || kind == 'f' {
+		return text == "true" || text == "false"
+	}
+	return (text[0] == '-' || text[0] >= '0' && text[0] <= '9') && json.Valid([]byte(text))
+}
@@ -0,0 +1,155 @@
+package table
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/lipgloss/v2"
+)
+
+// aggregates are the functions supported by --footer.
+var aggregates = []string{"sum", "avg", "min", "max", "count", "distinct"}
+
+// footerColumns parses the --footer flag into the aggregate of each column.
+func (o Options) footerColumns(header []string) (map[int]string, error) {
+	funcs, err := parseColumnFlags(header, o.Footer)
+	if err != nil {
+		return nil, err
+	}
+	for i, fn := range funcs {
+		if !slices.Contains(aggregates, fn) {
+			return nil, fmt.Errorf("invalid aggregate %q for column %q, expected sum, avg, min, max, count or distinct", fn, header[i])
+		}
+	}
+	return funcs, nil
+}
+
+// aggregate is the running value of the aggregate of a column, so that rows
+// read in the background are only added once rather than going over all of
+// them with each batch.
+type aggregate struct {
+	fn       string
+	count    int
+	numbers  int
+	sum      float64
+	min, max float64
+	distinct map[string]bool
+}
+
+// add counts a cell in the aggregate. Cells that aren't numbers are left out
+// of sum, avg, min and max, empty cells out of count and distinct.
+func (a *aggregate) add(cell string) {
+	if n, ok := parseNumber(cell); ok {
+		if a.numbers == 0 {
+			a.min, a.max = n, n
+		}
+		a.numbers++
+		a.sum += n
+		a.min, a.max = min(a.min, n), max(a.max, n)
+	}
+	if cell = strings.TrimSpace(cell); cell != "" {
+		a.count++
+		if a.fn == "distinct" {
+			a.distinct[cell] = true
+		}
+	}
+}
+
+// value returns the aggregate, formatted like the cells of its column.
+func (a *aggregate) value(format columnFormat, thousands bool) string {
+	switch a.fn {
+	case "count":
+		return strconv.Itoa(a.count)
+	case "distinct":
+		return strconv.Itoa(len(a.distinct))
+	}
+	if a.numbers == 0 {
+		return ""
+	}
+	v := a.sum
+	switch a.fn {
+	case "avg":
+		v /= float64(a.numbers)
+	case "min":
+		v = a.min
+	case "max":
+		v = a.max
+	}
+	// Round away the floating point noise of sums and averages.
+	v = math.Round(v*1e6) / 1e6
+	return format.value(strconv.FormatFloat(v, 'f', -1, 64), thousands)
+}
+
+// totals are the aggregates of the columns having one.
+type totals map[int]*aggregate
+
+func newTotals(funcs map[int]string) totals {
+	if len(funcs) == 0 {
+		return nil
+	}
+	t := make(totals, len(funcs))
+	for i, fn := range funcs {
+		t[i] = &aggregate{fn: fn}
+		if fn == "distinct" {
+			t[i].distinct = map[string]bool{}
+		}
+	}
+	return t
+}
+
+// add adds the cells of the rows to the aggregates.
+func (t totals) add(rows ...[]string) {
+	for _, row := range rows {
+		for i, a := range t {
+			if i < len(row) {
+				a.add(row[i])
+			}
+		}
+	}
+}
+
+// row returns the footer, with the aggregates prefixed by their name. Columns
+// without aggregate are left empty.
+func (t totals) row(formats []columnFormat, thousands bool) []string {
+	if len(t) == 0 {
+		return nil
+	}
+	footer := make([]string, len(formats))
+	for i, a := range t {
+		footer[i] = a.fn + " " + a.value(formats[i], thousands)
+	}
+	return footer
+}
+
+// addToFooter adds a batch of rows to the footer.
+func (m *model) addToFooter(rows []table.Row) {
+	for _, row := range rows {
+		m.totals.add(row)
+	}
+	m.footer = m.totals.row(m.formats, m.thousands)
+}
+
+// updateFooter computes the aggregates again over all the rows, as an edited
+// cell can't be taken out of them.
+func (m *model) updateFooter() {
+	m.totals = newTotals(m.footerFuncs)
+	m.addToFooter(m.rows)
+}
+
+// footerView renders the footer below the table, lined up with the visible
+// columns.
+func (m model) footerView() string {
+	cells := make([]string, 0, len(m.visible)+1)
+	if m.gutter > 0 {
+		cells = append(cells, m.cellStyle.Render(strings.Repeat(" ", m.gutter)))
+	}
+	for _, c := range m.visible {
+		cell := alignCell(m.footer[c], m.widths[c], m.formats[c].align)
+		cells = append(cells, m.cellStyle.Render(m.footerStyle.Render(cell)))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+}
@@ -0,0 +1,247 @@
+package table
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// columnFormat describes how the cells of a column are displayed.
+type columnFormat struct {
+	align    lipgloss.Position
+	numeric  bool
+	verb     string
+	minWidth int
+	maxWidth int
+	weight   int
+}
+
+// columnIndex finds a column either by its name or by its 1-based position.
+func columnIndex(header []string, ref string) (int, error) {
+	for i, name := range header {
+		if name == ref {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n > 0 && n <= len(header) {
+		return n - 1, nil
+	}
+	return -1, fmt.Errorf("unknown column %q", ref)
+}
+
+// parseColumnFlags parses flags in the column=value form into a map of
+// column indexes to values.
+func parseColumnFlags(header []string, flags []string) (map[int]string, error) {
+	values := make(map[int]string, len(flags))
+	for _, flag := range flags {
+		ref, value, ok := strings.Cut(flag, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid value %q, expected column=value", flag)
+		}
+		i, err := columnIndex(header, ref)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+var alignments = map[string]lipgloss.Position{
+	"left":   lipgloss.Left,
+	"center": lipgloss.Center,
+	"right":  lipgloss.Right,
+}
+
+// numberVerb matches the printf verbs --format accepts: integers, decimals and
+// exponents, with their flags, width and precision.
+var numberVerb = regexp.MustCompile(`^%[-+# 0]*[0-9]*(\.[0-9]*)?[dfFeEgG]$`)
+
+// columnFormats builds the format of every column from the --align, --format,
+// --min-width, --max-width and --weight flags. Numeric columns are detected and right aligned
+// unless told otherwise.
+func (o Options) columnFormats(header []string, data [][]string) ([]columnFormat, error) {
+	aligns, err := parseColumnFlags(header, o.Align)
+	if err != nil {
+		return nil, err
+	}
+	verbs, err := parseColumnFlags(header, o.Format)
+	if err != nil {
+		return nil, err
+	}
+	minWidths, err := parseColumnFlags(header, o.MinWidth)
+	if err != nil {
+		return nil, err
+	}
+	maxWidths, err := parseColumnFlags(header, o.MaxWidth)
+	if err != nil {
+		return nil, err
+	}
+	weights, err := parseColumnFlags(header, o.Weight)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := make([]columnFormat, len(header))
+	for i := range header {
+		f := columnFormat{
+			align:   lipgloss.Left,
+			numeric: isNumericColumn(data, i),
+			verb:    verbs[i],
+			weight:  1,
+		}
+		if f.numeric {
+			f.align = lipgloss.Right
+		}
+		if f.verb != "" && !numberVerb.MatchString(f.verb) {
+			return nil, fmt.Errorf("invalid format %q, expected a number verb such as %%.2f or %%d", f.verb)
+		}
+		if a, ok := aligns[i]; ok {
+			pos, ok := alignments[a]
+			if !ok {
+				return nil, fmt.Errorf("invalid alignment %q, expected left, center or right", a)
+			}
+			f.align = pos
+		}
+		if w, ok := maxWidths[i]; ok {
+			f.maxWidth, err = strconv.Atoi(w)
+			if err != nil || f.maxWidth < 1 {
+				return nil, fmt.Errorf("invalid max width %q", w)
+			}
+		}
+		if w, ok := minWidths[i]; ok {
+			f.minWidth, err = strconv.Atoi(w)
+			if err != nil || f.minWidth < 1 {
+				return nil, fmt.Errorf("invalid min width %q", w)
+			}
+		}
+		if w, ok := weights[i]; ok {
+			f.weight, err = strconv.Atoi(w)
+			if err != nil || f.weight < 0 {
+				return nil, fmt.Errorf("invalid weight %q", w)
+			}
+		}
+		formats[i] = f
+	}
+	return formats, nil
+}
+
+// isNumericColumn reports whether all the non-empty cells of a column are
+// numbers.
+func isNumericColumn(data [][]string, col int) bool {
+	numeric := false
+	for _, row := range data {
+		if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+			continue
+		}
+		if _, ok := parseNumber(row[col]); !ok {
+			return false
+		}
+		numeric = true
+	}
+	return numeric
+}
+
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f, err == nil
+}
+
+// value formats a single cell of the column.
+func (f columnFormat) value(cell string, thousands bool) string {
+	n, ok := parseNumber(cell)
+	if !ok {
+		return cell
+	}
+	if f.verb != "" {
+		if strings.HasSuffix(f.verb, "d") {
+			cell = fmt.Sprintf(f.verb, int64(n))
+		} else {
+			cell = fmt.Sprintf(f.verb, n)
+		}
+	}
+	if thousands && f.numeric {
+		cell = groupThousands(cell)
+	}
+	return cell
+}
+
+// groupThousands inserts thousands separators in the integer part of a
+// number, e.g. -1234567.89 becomes -1,234,567.89.
+func groupThousands(s string) string {
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return s
+	}
+	end := start
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+
+	digits := s[start:end]
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return s[:start] + b.String() + s[end:]
+}
+
+// formatData returns a copy of the data with the number formatting applied.
+func (o Options) formatData(data [][]string, formats []columnFormat) [][]string {
+	out := make([][]string, 0, len(data))
+	for _, row := range data {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell
+			if i < len(formats) {
+				cells[i] = formats[i].value(cell, o.Thousands)
+			}
+		}
+		out = append(out, cells)
+	}
+	return out
+}
+
+// fit truncates or wraps the lines of the cell to the given width.
+func fit(cell string, width int, wrap bool, tail string) string {
+	if width <= 0 || lipgloss.Width(cell) <= width {
+		return cell
+	}
+	if wrap {
+		return ansi.Wrap(cell, width, "")
+	}
+	lines := strings.Split(cell, "\n")
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, width, tail)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clampLines keeps the first lines of the cell, ending the last one with the
+// continuation marker when some were cut. The marker is kept within width,
+// unless it is 0.
+func clampLines(cell string, height int, marker string, width int) string {
+	lines := strings.Split(cell, "\n")
+	if height <= 0 || len(lines) <= height {
+		return cell
+	}
+	lines = lines[:height]
+	last := lines[height-1]
+	if width > 0 {
+		last = ansi.Truncate(last, max(width-ansi.StringWidth(marker), 0), "")
+	}
+	lines[height-1] = last + marker
+	return strings.Join(lines, "\n")
+}
+
+// alignCell pads the cell to the column width according to its alignment.
+func alignCell(cell string, width int, pos lipgloss.Position) string {
+	return lipgloss.PlaceHorizontal(width, pos, ansi.Truncate(cell, width, "…"))
+}
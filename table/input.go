@@ -0,0 +1,427 @@
+package table
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Input formats supported by the table command.
+const (
+	formatAuto     = "auto"
+	formatCSV      = "csv"
+	formatTSV      = "tsv"
+	formatJSON     = "json"
+	formatJSONL    = "jsonl"
+	formatMarkdown = "markdown"
+)
+
+// rowFunc returns the next row of the input, or io.EOF once all of them were
+// read.
+type rowFunc func() ([]string, error)
+
+// jsonValues keeps the JSON values the rows were read from, so that edited
+// data can be written back without losing what the table doesn't show.
+type jsonValues struct {
+	header json.RawMessage // the array of column names, when read from the input
+	rows   []json.RawMessage
+}
+
+// openTable starts parsing the input in the configured format. It returns the
+// column names and a function to read the rows one by one.
+//
+// For JSON objects the column names are the keys found in the first sample
+// objects, or all of them if sample is negative. The JSON values read are
+// kept in values, unless it's nil.
+func (o Options) openTable(r io.Reader, sample int, values *jsonValues) ([]string, rowFunc, error) {
+	br := bufio.NewReader(r)
+	format := o.InputFormat
+	if format == formatAuto {
+		format = sniffFormat(br, o.Separator)
+	}
+
+	switch format {
+	case formatJSON, formatJSONL:
+		return o.openJSON(br, format, sample, values)
+	case formatMarkdown:
+		return o.withHeader(markdownRows(br))
+	case formatTSV:
+		return o.withHeader(tsvRows(br))
+	default:
+		return o.withHeader(o.csvRows(br))
+	}
+}
+
+// withHeader uses the first row as the column names, unless they were given
+// explicitly.
+func (o Options) withHeader(next rowFunc) ([]string, rowFunc, error) {
+	if len(o.Columns) > 0 {
+		return o.Columns, next, nil
+	}
+	header, err := next()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse columns")
+	}
+	return header, next, nil
+}
+
+const sniffSize = 4096
+
+// firstByte returns the first non-whitespace byte of the input without
+// consuming it.
+func firstByte(br *bufio.Reader) byte {
+	peek, _ := br.Peek(sniffSize)
+	peek = bytes.TrimLeft(peek, " \t\r\n")
+	if len(peek) == 0 {
+		return 0
+	}
+	return peek[0]
+}
+
+// sniffFormat guesses the input format by peeking at the beginning of the
+// input.
+func sniffFormat(br *bufio.Reader, separator string) string {
+	switch first := firstByte(br); {
+	case first == 0:
+		return formatCSV
+	case first == '[' && startsJSON(br):
+		return formatJSON
+	case first == '{' && startsJSON(br):
+		return formatJSONL
+	case first == '|' && separator != "|":
+		return formatMarkdown
+	}
+
+	peek, _ := br.Peek(sniffSize)
+	peek = bytes.TrimLeft(peek, " \t\r\n")
+	line, _, _ := bytes.Cut(peek, []byte("\n"))
+	if separator != "\t" && bytes.ContainsRune(line, '\t') && !bytes.Contains(line, []byte(separator)) {
+		return formatTSV
+	}
+	return formatCSV
+}
+
+// startsJSON reports whether the beginning of the input is valid JSON as far
+// as it goes, so that a CSV header such as [id],name isn't taken for an array.
+func startsJSON(br *bufio.Reader) bool {
+	peek, _ := br.Peek(sniffSize)
+	dec := json.NewDecoder(bytes.NewReader(peek))
+	for {
+		if _, err := dec.Token(); err != nil {
+			return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func (o Options) csvRows(r io.Reader) rowFunc {
+	reader := csv.NewReader(r)
+	reader.LazyQuotes = o.LazyQuotes
+	reader.FieldsPerRecord = o.FieldsPerRecord
+	reader.Comma = []rune(o.Separator)[0]
+
+	return func() ([]string, error) {
+		row, err := reader.Read()
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid data provided")
+		}
+		return row, err //nolint:wrapcheck
+	}
+}
+
+// lineRows splits each non-empty line of the input into a row. Lines for
+// which split returns false are skipped.
+func lineRows(r io.Reader, split func(line string) ([]string, bool)) rowFunc {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, bufio.MaxScanTokenSize*1024)
+	return func() ([]string, error) {
+		for scanner.Scan() {
+			line := strings.TrimSuffix(scanner.Text(), "\r")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if row, ok := split(line); ok {
+				return row, nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("invalid data provided: %w", err)
+		}
+		return nil, io.EOF
+	}
+}
+
+// tsvRows reads tab separated values. Unlike CSV, TSV has no quoting, so each
+// line is simply split on tabs.
+func tsvRows(r io.Reader) rowFunc {
+	return lineRows(r, func(line string) ([]string, bool) {
+		return strings.Split(line, "\t"), true
+	})
+}
+
+// markdownRows reads a Markdown (GFM) pipe table.
+func markdownRows(r io.Reader) rowFunc {
+	return lineRows(r, func(line string) ([]string, bool) {
+		cells := splitMarkdownRow(strings.TrimSpace(line))
+		return cells, !isMarkdownDelimiterRow(cells)
+	})
+}
+
+// splitMarkdownRow splits a Markdown table row into its cells, honoring
+// escaped pipes.
+func splitMarkdownRow(line string) []string {
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = strings.TrimSuffix(line, "|")
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// isMarkdownDelimiterRow reports whether the cells form the row separating
+// the header from the body, e.g. |---|:--:|.
+func isMarkdownDelimiterRow(cells []string) bool {
+	for _, cell := range cells {
+		cell = strings.Trim(cell, ":")
+		if cell == "" || strings.Trim(cell, "-") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// openJSON reads either a JSON array or a stream of JSON values (JSONL). Each
+// value is either an object, whose keys become the columns, or an array of
+// cells.
+func (o Options) openJSON(br *bufio.Reader, format string, sample int, values *jsonValues) ([]string, rowFunc, error) {
+	dec := json.NewDecoder(br)
+	dec.UseNumber()
+
+	// A JSON array holds all the rows, while JSONL is a stream of rows.
+	inArray := format == formatJSON && firstByte(br) == '['
+	if inArray {
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, fmt.Errorf("invalid data provided: %w", err)
+		}
+	}
+
+	nextValue := func() (json.RawMessage, error) {
+		if inArray && !dec.More() {
+			return nil, io.EOF
+		}
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid data provided: %w", err)
+		}
+		return raw, nil
+	}
+
+	first, err := nextValue()
+	if errors.Is(err, io.EOF) {
+		return o.withHeader(func() ([]string, error) { return nil, io.EOF })
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !isJSONObject(first) {
+		toRow := func(raw json.RawMessage) ([]string, error) {
+			var cells []json.RawMessage
+			if err := json.Unmarshal(raw, &cells); err != nil {
+				return nil, fmt.Errorf("invalid data provided: expected an array or an object")
+			}
+			row := make([]string, 0, len(cells))
+			for _, cell := range cells {
+				row = append(row, jsonScalar(cell))
+			}
+			return row, nil
+		}
+		pending := first
+		header, next, err := o.withHeader(func() ([]string, error) {
+			raw := pending
+			pending = nil
+			if raw == nil {
+				var err error
+				if raw, err = nextValue(); err != nil {
+					return nil, err
+				}
+			}
+			if values != nil {
+				values.rows = append(values.rows, raw)
+			}
+			return toRow(raw)
+		})
+		if err == nil && len(header) == 0 {
+			return nil, nil, fmt.Errorf("invalid data provided: no columns")
+		}
+		if err == nil && values != nil && len(o.Columns) == 0 {
+			values.header, values.rows = values.rows[0], values.rows[1:]
+		}
+		return header, next, err
+	}
+
+	// Buffer the sample objects to find out which keys they have.
+	if sample == 0 {
+		sample = 1
+	}
+	var keys []string
+	seen := map[string]bool{}
+	var buffered []map[string]string
+	flatten := func(raw json.RawMessage) (map[string]string, error) {
+		if !isJSONObject(raw) {
+			return nil, fmt.Errorf("invalid data provided: expected an object")
+		}
+		record := map[string]string{}
+		var order []string
+		if err := flattenJSON("", raw, record, &order); err != nil {
+			return nil, fmt.Errorf("invalid data provided: %w", err)
+		}
+		if values != nil {
+			values.rows = append(values.rows, raw)
+		}
+		if len(o.Columns) == 0 && (sample < 0 || len(buffered) < sample) {
+			for _, key := range order {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		return record, nil
+	}
+
+	raw := first
+	for raw != nil && (sample < 0 || len(buffered) < sample) {
+		record, err := flatten(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		buffered = append(buffered, record)
+		if raw, err = nextValue(); errors.Is(err, io.EOF) {
+			raw = nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+	pending := raw
+
+	if len(o.Columns) > 0 {
+		keys = o.Columns
+	}
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("invalid data provided: no columns")
+	}
+	toRow := func(record map[string]string) []string {
+		row := make([]string, 0, len(keys))
+		for _, key := range keys {
+			row = append(row, record[key])
+		}
+		return row
+	}
+
+	return keys, func() ([]string, error) {
+		if len(buffered) > 0 {
+			record := buffered[0]
+			buffered = buffered[1:]
+			return toRow(record), nil
+		}
+		raw := pending
+		pending = nil
+		if raw == nil {
+			var err error
+			if raw, err = nextValue(); err != nil {
+				return nil, err
+			}
+		}
+		record, err := flatten(raw)
+		if err != nil {
+			return nil, err
+		}
+		return toRow(record), nil
+	}, nil
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && raw[0] == '{'
+}
+
+// flattenJSON stores every leaf of the given JSON value in the record, using
+// the dot separated path of object keys as the column name. The order in
+// which keys appear in the input is kept.
+func flattenJSON(prefix string, raw json.RawMessage, record map[string]string, order *[]string) error {
+	if !isJSONObject(raw) {
+		if _, ok := record[prefix]; !ok {
+			*order = append(*order, prefix)
+		}
+		record[prefix] = jsonScalar(raw)
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return err //nolint:wrapcheck
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		key, _ := tok.(string)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err //nolint:wrapcheck
+		}
+		if err := flattenJSON(key, value, record, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonScalar renders a JSON value as a table cell. Strings are unquoted, null
+// becomes empty and arrays or objects are kept as compact JSON.
+func jsonScalar(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) == 0, string(raw) == "null":
+		return ""
+	case raw[0] == '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+	case raw[0] == '[', raw[0] == '{':
+		var b bytes.Buffer
+		if err := json.Compact(&b, raw); err == nil {
+			return b.String()
+		}
+	}
+	return string(raw)
+}
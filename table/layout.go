@@ -0,0 +1,204 @@
+package table
+
+import (
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/lipgloss/v2"
+)
+
+// layout picks the columns to display: the frozen ones, followed by as many
+// of the others as fit the terminal, starting at the horizontal offset. It
+// then hands them to the table widget.
+func (m *model) layout() {
+	shown := m.shown()
+	n := len(shown)
+	frozen := min(max(m.freeze, 0), n)
+	active := slices.Index(shown, m.col)
+
+	// Keep the active cell in view while editing.
+	if m.editable && active >= frozen && active < m.colOffset {
+		m.colOffset = active
+	}
+	m.colOffset = max(min(m.colOffset, n-1), frozen)
+	m.fitWidths(shown)
+
+	m.visible = nil
+	for n > 0 {
+		m.visible = m.fitColumns(shown, frozen)
+		last := slices.Index(shown, m.visible[len(m.visible)-1])
+		if !m.editable || active <= last || m.colOffset >= active {
+			break
+		}
+		m.colOffset++
+	}
+	m.hiddenLeft = m.colOffset > frozen
+	m.hiddenRight = n > 0 && m.visible[len(m.visible)-1] != shown[n-1]
+
+	columns := make([]table.Column, 0, len(m.visible)+1)
+	if m.gutter > 0 {
+		columns = append(columns, table.Column{Width: m.gutter})
+	}
+	for _, c := range m.visible {
+		columns = append(columns, m.columns[c])
+	}
+	width := 0
+	for _, column := range columns {
+		width += column.Width + m.cellFrame
+	}
+
+	// The table widget expects each row to have as many cells as there are
+	// columns, the rows are rebuilt by sync.
+	m.table.SetRows(nil)
+	m.table.SetColumns(columns)
+	m.table.SetWidth(width)
+	m.resizeDetail()
+	m.sync()
+}
+
+// fitWidths shares the available width between the shown columns, shrinking
+// the most flexible ones first.
+func (m *model) fitWidths(shown []int) {
+	widths := make([]int, len(shown))
+	mins := make([]int, len(shown))
+	weights := make([]int, len(shown))
+	for i, c := range shown {
+		widths[i], mins[i], weights[i] = m.natural[c], m.mins[c], m.weights[c]
+	}
+	if available := m.availableWidth(); m.fit && available > 0 {
+		if m.gutter > 0 {
+			available -= m.gutter + m.cellFrame
+		}
+		widths = shareWidths(widths, mins, weights, m.cellFrame, available)
+	}
+	for i, c := range shown {
+		m.widths[c] = widths[i]
+		m.columns[c].Width = widths[i]
+		m.columns[c].Title = alignCell(m.header[c], widths[i], m.formats[c].align)
+	}
+}
+
+// fitColumns returns the indexes of the frozen columns followed by the ones
+// fitting the remaining width, always keeping at least one of them.
+func (m model) fitColumns(shown []int, frozen int) []int {
+	visible := make([]int, 0, len(shown))
+	used := 0
+	if m.gutter > 0 {
+		used += m.gutter + m.cellFrame
+	}
+	for _, c := range shown[:frozen] {
+		visible = append(visible, c)
+		used += m.widths[c] + m.cellFrame
+	}
+
+	available := m.availableWidth()
+	for _, c := range shown[m.colOffset:] {
+		cost := m.widths[c] + m.cellFrame
+		if available > 0 && used+cost > available && len(visible) > frozen {
+			break
+		}
+		visible = append(visible, c)
+		used += cost
+	}
+	if len(visible) == 0 && len(shown) > 0 {
+		visible = append(visible, shown[0])
+	}
+	return visible
+}
+
+// availableWidth returns the width left for the columns, or 0 as long as the
+// size of the terminal is unknown.
+func (m model) availableWidth() int {
+	if m.width <= 0 {
+		return 0
+	}
+	// Leave room for the padding, the scroll hints on both sides and the
+	// detail pane.
+	return max(m.width-m.padding[1]-m.padding[3]-2-m.detailSize(), 1)
+}
+
+// scroll moves the scrollable columns by delta.
+func (m *model) scroll(delta int) {
+	if delta > 0 && !m.hiddenRight || delta < 0 && !m.hiddenLeft {
+		return
+	}
+	m.colOffset += delta
+	m.layout()
+}
+
+// scrollHints marks the sides of the header beyond which columns are hidden.
+func (m model) scrollHints(view string) string {
+	if m.availableWidth() == 0 {
+		return view
+	}
+	left, right := " ", " "
+	if m.hiddenLeft {
+		left = "‹"
+	}
+	if m.hiddenRight {
+		right = "›"
+	}
+
+	lines := strings.Split(view, "\n")
+	width := lipgloss.Width(lines[0])
+	for i, line := range lines {
+		if i > 0 {
+			lines[i] = " " + line
+			continue
+		}
+		lines[i] = left + line + strings.Repeat(" ", max(width-lipgloss.Width(line), 0)) + right
+	}
+	return strings.Join(lines, "\n")
+}
+
+// minColumnWidth is the width below which columns are not shrunk, unless told
+// otherwise with --min-width.
+const minColumnWidth = 5
+
+// widthLimits returns the minimum width and the weight of each column when
+// shrinking them to fit. Columns with an explicit width are left alone.
+func (o Options) widthLimits(formats []columnFormat, natural []int) ([]int, []int) {
+	mins := make([]int, len(natural))
+	weights := make([]int, len(natural))
+	for i, w := range natural {
+		mins[i] = min(w, minColumnWidth)
+		if formats[i].minWidth > 0 {
+			mins[i] = min(w, formats[i].minWidth)
+		}
+		weights[i] = formats[i].weight
+		if i < len(o.Widths) && o.Widths[i] > 0 {
+			weights[i] = 0
+		}
+	}
+	return mins, weights
+}
+
+// shareWidths shrinks the columns until they fit the available width, each
+// of them taking frame extra cells. The column with the most room above its
+// minimum, relative to its weight, gives up a cell first, so wide flexible
+// columns shrink before narrow ones. Columns with a weight of 0 keep their
+// width.
+func shareWidths(widths, mins, weights []int, frame, available int) []int {
+	out := slices.Clone(widths)
+	total := 0
+	for _, w := range out {
+		total += w + frame
+	}
+
+	slack := func(i int) int { return (out[i] - mins[i]) * weights[i] }
+	for total > available {
+		best := -1
+		for i := range out {
+			if slack(i) > 0 && (best < 0 || slack(i) > slack(best)) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		out[best]--
+		total--
+	}
+	return out
+}
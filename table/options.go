@@ -9,22 +9,63 @@ import (
 // Options is the customization options for the table command.
 type Options struct {
 	Separator       string   `short:"s" help:"Row separator" default:","`
-	Columns         []string `short:"c" help:"Column names"`
+	InputFormat     string   `help:"Format of the input data (auto detects it from the content)" enum:"auto,csv,tsv,json,jsonl,markdown" default:"auto" env:"GUM_TABLE_INPUT_FORMAT"`
+	Columns         []string `short:"c" help:"Column names (for JSON input, the keys to show and their order)"`
 	Widths          []int    `short:"w" help:"Column widths"`
+	MaxWidth        []string `help:"Maximum width of a column, longer cells are truncated (column=width)" placeholder:"COLUMN=WIDTH"`
+	MinWidth        []string `help:"Minimum width of a column when shrinking the table to fit the terminal (column=width)" placeholder:"COLUMN=WIDTH"`
+	Weight          []string `help:"How much a column shrinks relative to the others to fit the terminal, 0 keeps its width (column=weight)" placeholder:"COLUMN=WEIGHT"`
+	Fit             bool     `help:"Shrink the columns to fit the terminal width" default:"true" negatable:"" env:"GUM_TABLE_FIT"`
+	Wrap            bool     `help:"Wrap cells longer than their maximum width instead of truncating them (print only)" default:"false" env:"GUM_TABLE_WRAP"`
+	Align           []string `help:"Alignment of a column: left, center or right (column=align). Numeric columns are right aligned by default" placeholder:"COLUMN=ALIGN"`
+	Format          []string `help:"Printf verb used to format the numbers of a column, e.g. price=%.2f (column=verb)" placeholder:"COLUMN=VERB"`
+	Thousands       bool     `help:"Group the digits of numeric columns with thousands separators" default:"false" env:"GUM_TABLE_THOUSANDS"`
+	Zebra           bool     `help:"Alternate the style of the rows" default:"false" env:"GUM_TABLE_ZEBRA"`
+	ZebraStyle      string   `help:"Style of every other row, e.g. bg=236 (with --zebra)" default:"bg=236" env:"GUM_TABLE_ZEBRA_STYLE"`
+	ColumnStyle     []string `help:"Style of the cells of a column, e.g. name:fg=212 (column:style)" placeholder:"COLUMN:STYLE" sep:"none"`
+	Highlight       []string `help:"Style of the rows matching a condition, e.g. status=FAILED:fg=196,bold. Columns are compared with =, !=, ~ (regex), >, >=, < or <=" placeholder:"CONDITION:STYLE" sep:"none"`
+	Select          []string `help:"Columns to show, in order" placeholder:"COLUMN"`
+	Where           []string `help:"Only show the rows matching a condition, e.g. status!=ok or age>30. Columns are compared with =, !=, ~ (regex), >, >=, < or <=" placeholder:"CONDITION" sep:"none"`
+	Sort            []string `help:"Columns to sort the rows by, suffixed with :desc for descending order" placeholder:"COLUMN"`
+	MaxRows         int      `help:"Maximum number of rows to show (0 shows all of them)" default:"0" env:"GUM_TABLE_MAX_ROWS"`
+	Footer          []string `help:"Aggregate shown below a column: sum, avg, min, max, count or distinct (column=aggregate)" placeholder:"COLUMN=AGGREGATE"`
+	MaxRowHeight    int      `help:"Maximum number of lines of a row with multi-line cells (0 shows all of them)" default:"0" env:"GUM_TABLE_MAX_ROW_HEIGHT"`
+	Continuation    string   `help:"Marker ending multi-line cells cut by --max-row-height" default:"…" env:"GUM_TABLE_CONTINUATION"`
 	Height          int      `help:"Table height" default:"0"`
+	Freeze          int      `help:"Number of leading columns that stay in place when scrolling horizontally" default:"0" env:"GUM_TABLE_FREEZE"`
+	Detail          bool     `help:"Show all the fields of the highlighted row in a pane, toggle it with i" default:"false" env:"GUM_TABLE_DETAIL"`
+	DetailPosition  string   `help:"Position of the detail pane" enum:"right,bottom" default:"right" env:"GUM_TABLE_DETAIL_POSITION"`
+	ColumnsState    string   `help:"File the order and visibility of the columns are restored from and saved to" type:"path" env:"GUM_TABLE_COLUMNS_STATE"`
 	Print           bool     `short:"p" help:"static print" default:"false"`
+	Output          string   `short:"o" help:"Format used to print the table (implies --print)" enum:"table,markdown,csv,tsv,json,html,ascii" default:"table" env:"GUM_TABLE_OUTPUT"`
 	File            string   `short:"f" help:"file path" default:""`
 	Border          string   `short:"b" help:"border style" default:"rounded" enum:"rounded,thick,normal,hidden,double,none"`
 	ShowHelp        bool     `help:"Show help keybinds" default:"true" negatable:"" env:"GUM_TABLE_SHOW_HELP"`
 	HideCount       bool     `help:"Hide item count on help keybinds" default:"false" negatable:"" env:"GUM_TABLE_HIDE_COUNT"`
 	LazyQuotes      bool     `help:"If LazyQuotes is true, a quote may appear in an unquoted field and a non-doubled quote may appear in a quoted field" default:"false" env:"GUM_TABLE_LAZY_QUOTES"`
 	FieldsPerRecord int      `help:"Sets the number of expected fields per record" default:"0" env:"GUM_TABLE_FIELDS_PER_RECORD"`
+	Stream          bool     `help:"Show the first rows right away and keep reading the rest in the background (not with --editable, --sort, --print or --output)" default:"false" env:"GUM_TABLE_STREAM"`
+	Sample          int      `help:"Number of rows used to compute the column widths and formats when streaming" default:"1000" env:"GUM_TABLE_SAMPLE"`
 
-	BorderStyle   style.Styles  `embed:"" prefix:"border." envprefix:"GUM_TABLE_BORDER_"`
-	CellStyle     style.Styles  `embed:"" prefix:"cell." envprefix:"GUM_TABLE_CELL_"`
-	HeaderStyle   style.Styles  `embed:"" prefix:"header." envprefix:"GUM_TABLE_HEADER_"`
-	SelectedStyle style.Styles  `embed:"" prefix:"selected." set:"defaultForeground=212" envprefix:"GUM_TABLE_SELECTED_"`
-	ReturnColumn  int           `short:"r" help:"Which column number should be returned instead of whole row as string. Default=0 returns whole Row" default:"0"`
-	Timeout       time.Duration `help:"Timeout until choose returns selected element" default:"0s" env:"GUM_TABLE_TIMEOUT"`
-	Padding       string        `help:"Padding" default:"${defaultPadding}" group:"Style Flags" env:"GUM_TABLE_PADDING"`
+	BorderStyle      style.Styles  `embed:"" prefix:"border." envprefix:"GUM_TABLE_BORDER_"`
+	CellStyle        style.Styles  `embed:"" prefix:"cell." envprefix:"GUM_TABLE_CELL_"`
+	HeaderStyle      style.Styles  `embed:"" prefix:"header." envprefix:"GUM_TABLE_HEADER_"`
+	SelectedStyle    style.Styles  `embed:"" prefix:"selected." set:"defaultForeground=212" envprefix:"GUM_TABLE_SELECTED_"`
+	FooterStyle      style.Styles  `embed:"" prefix:"footer." set:"defaultBold=true" envprefix:"GUM_TABLE_FOOTER_"`
+	DetailKeyStyle   style.Styles  `embed:"" prefix:"detail-key." set:"defaultForeground=212" envprefix:"GUM_TABLE_DETAIL_KEY_"`
+	ActiveCellStyle  style.Styles  `embed:"" prefix:"active-cell." set:"defaultUnderline=true" envprefix:"GUM_TABLE_ACTIVE_CELL_"`
+	EditedStyle      style.Styles  `embed:"" prefix:"edited." set:"defaultForeground=214" set:"defaultItalic=true" envprefix:"GUM_TABLE_EDITED_"`
+	ReturnColumn     []string      `short:"r" help:"Columns to return instead of the whole row, by name or number. Default=0 returns whole Row" placeholder:"COLUMN"`
+	ReturnFormat     string        `help:"Format of the returned rows: csv, tsv, json or env (NAME=value lines)" enum:"csv,tsv,json,env" default:"csv" env:"GUM_TABLE_RETURN_FORMAT"`
+	Limit            int           `help:"Maximum number of rows to pick" default:"1" group:"Selection"`
+	NoLimit          bool          `help:"Pick unlimited number of rows (ignores limit)" group:"Selection"`
+	Ordered          bool          `help:"Maintain the order in which the rows were selected" env:"GUM_TABLE_ORDERED"`
+	SelectedPrefix   string        `help:"Prefix to show on selected rows (hidden if limit is 1)" default:"✓" env:"GUM_TABLE_SELECTED_PREFIX"`
+	UnselectedPrefix string        `help:"Prefix to show on unselected rows (hidden if limit is 1)" default:"•" env:"GUM_TABLE_UNSELECTED_PREFIX"`
+	OutputDelimiter  string        `help:"Row delimiter when writing multiple rows to STDOUT" default:"\n" env:"GUM_TABLE_OUTPUT_DELIMITER"`
+	Editable         bool          `help:"Edit the cells and write the whole data back in the input format" default:"false" env:"GUM_TABLE_EDITABLE"`
+	Readonly         []string      `help:"Columns that can't be edited" placeholder:"COLUMN"`
+	Validate         []string      `help:"Regular expression the edited cells of a column must match (column=regex)" placeholder:"COLUMN=REGEX" sep:"none"`
+	Timeout          time.Duration `help:"Timeout until choose returns selected element" default:"0s" env:"GUM_TABLE_TIMEOUT"`
+	Padding          string        `help:"Padding" default:"${defaultPadding}" group:"Style Flags" env:"GUM_TABLE_PADDING"`
 }
@@ -0,0 +1,385 @@
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/gum/v2/internal/tty"
+	"charm.land/gum/v2/style"
+	"charm.land/lipgloss/v2"
+	ltable "charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+)
+
+// Output formats supported by the table command when printing.
+const (
+	outputTable    = "table"
+	outputMarkdown = "markdown"
+	outputCSV      = "csv"
+	outputTSV      = "tsv"
+	outputJSON     = "json"
+	outputHTML     = "html"
+	outputASCII    = "ascii"
+)
+
+// print writes the whole table to stdout in the configured output format.
+func (o Options) print(header []string, data, display [][]string, footer []string, formats []columnFormat, styles table.Styles, styler styler) error {
+	raw := data
+	switch o.Output {
+	case outputTable, outputMarkdown, outputHTML, outputASCII:
+		// Formatting and widths only make sense for the formats meant to be
+		// read by humans, the data formats always keep the raw values.
+		if footer != nil {
+			display = append(display, footer)
+		}
+		limits := o.columnLimits(header, display, formats, styles.Cell.GetHorizontalFrameSize())
+		data = o.fitColumns(display, limits)
+		header = o.fitColumns([][]string{header}, limits)[0]
+		// The footer stays the last row of the table, the other formats set
+		// it apart.
+		if footer != nil && o.Output != outputTable {
+			data, footer = data[:len(raw)], data[len(raw)]
+		}
+	default:
+		if footer != nil {
+			return fmt.Errorf("--footer can't be combined with --output %s", o.Output)
+		}
+	}
+
+	var out string
+	var err error
+	switch o.Output {
+	case outputMarkdown:
+		// Markdown has no footer, the aggregates are a row of their own.
+		if footer != nil {
+			data = append(data, footer)
+		}
+		out = renderMarkdown(header, data)
+	case outputCSV:
+		out, err = renderCSV(header, data, []rune(o.Separator)[0])
+	case outputTSV:
+		out = renderTSV(header, data)
+	case outputJSON:
+		out, err = renderJSON(header, data)
+	case outputHTML:
+		out = renderHTML(header, data, footer)
+	case outputASCII:
+		out = renderASCII(header, data, footer)
+	default:
+		out = ltable.New().
+			Headers(header...).
+			Rows(data...).
+			BorderStyle(o.BorderStyle.ToLipgloss()).
+			Border(style.Border[o.Border]).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				s := styles.Cell
+				switch {
+				case row == ltable.HeaderRow:
+					s = styles.Header
+				case row >= len(raw):
+					s = o.FooterStyle.ToLipgloss().Padding(s.GetPadding())
+				default:
+					s = styler.style(s, row, raw[row], col)
+				}
+				if col < len(formats) {
+					return s.Align(formats[col].align)
+				}
+				return s
+			}).
+			Render()
+	}
+	if err != nil {
+		return err
+	}
+
+	tty.Println(strings.TrimSuffix(out, "\n"))
+	return nil
+}
+
+// columnLimits returns the width of each column, either given through
+// --widths or --max-width, further shrunk to fit the terminal when printing
+// to one. A limit of 0 leaves the column as is.
+func (o Options) columnLimits(header []string, data [][]string, formats []columnFormat, cellFrame int) []int {
+	limits := make([]int, len(formats))
+	for i, f := range formats {
+		limits[i] = f.maxWidth
+		if i < len(o.Widths) && o.Widths[i] > 0 && (limits[i] == 0 || o.Widths[i] < limits[i]) {
+			limits[i] = o.Widths[i]
+		}
+	}
+	if !o.Fit || o.Output == outputHTML {
+		return limits
+	}
+	width, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil || width <= 0 {
+		return limits
+	}
+
+	natural := columnWidths(header, data)
+	for i, limit := range limits {
+		if limit > 0 {
+			natural[i] = min(natural[i], limit)
+		}
+	}
+	// Each column is followed by a border, Markdown and ASCII tables pad the
+	// cells with a space on both sides.
+	frame := 3
+	if o.Output == outputTable {
+		frame = cellFrame + 1
+	}
+	mins, weights := o.widthLimits(formats, natural)
+	return shareWidths(natural, mins, weights, frame, width-1)
+}
+
+// fitColumns truncates the cells of each column to their limit. Cells are
+// wrapped instead when asked to, which only the lipgloss table supports.
+func (o Options) fitColumns(data [][]string, limits []int) [][]string {
+	wrap := o.Wrap && o.Output == outputTable
+	tail := "…"
+	if o.Output == outputASCII {
+		tail = "..."
+	}
+
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = cell
+			limit := 0
+			if i < len(limits) {
+				limit = limits[i]
+				cells[i] = fit(cell, limit, wrap, tail)
+			}
+			cells[i] = clampLines(cells[i], o.MaxRowHeight, o.Continuation, limit)
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+// columnWidths returns the width of the widest cell of each column.
+func columnWidths(header []string, data [][]string) []int {
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = lipgloss.Width(cell)
+	}
+	for _, row := range data {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], lipgloss.Width(cell))
+			}
+		}
+	}
+	return widths
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-ansi.StringWidth(s)))
+}
+
+func renderMarkdown(header []string, data [][]string) string {
+	escape := func(row []string) []string {
+		out := make([]string, len(row))
+		for i, cell := range row {
+			cell = strings.ReplaceAll(cell, "|", `\|`)
+			out[i] = strings.ReplaceAll(cell, "\n", "<br>")
+		}
+		return out
+	}
+
+	header = escape(header)
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		rows = append(rows, escape(row))
+	}
+	widths := columnWidths(header, rows)
+	for i := range widths {
+		// The delimiter row needs at least three dashes.
+		widths[i] = max(widths[i], 3)
+	}
+
+	var b strings.Builder
+	line := func(cells []string) {
+		b.WriteString("|")
+		for i, w := range widths {
+			var cell string
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString(" " + pad(cell, w) + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	line(header)
+	delimiters := make([]string, len(widths))
+	for i, w := range widths {
+		delimiters[i] = strings.Repeat("-", w)
+	}
+	line(delimiters)
+	for _, row := range rows {
+		line(row)
+	}
+	return b.String()
+}
+
+func renderCSV(header []string, data [][]string, separator rune) (string, error) {
+	var b strings.Builder
+	writer := csv.NewWriter(&b)
+	writer.Comma = separator
+	if err := writer.Write(header); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	if err := writer.WriteAll(data); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return b.String(), nil
+}
+
+func renderTSV(header []string, data [][]string) string {
+	// TSV has no quoting, so tabs and new lines within cells are replaced.
+	replacer := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ")
+	var b strings.Builder
+	for _, row := range append([][]string{header}, data...) {
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString("\t")
+			}
+			b.WriteString(replacer.Replace(cell))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderJSON renders the rows as an array of objects, keeping the order of
+// the columns.
+func renderJSON(header []string, data [][]string) (string, error) {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for r, row := range data {
+		if r > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("{")
+		for i, key := range header {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			var value string
+			if i < len(row) {
+				value = row[i]
+			}
+			k, err := json.Marshal(key)
+			if err != nil {
+				return "", err //nolint:wrapcheck
+			}
+			v, err := json.Marshal(value)
+			if err != nil {
+				return "", err //nolint:wrapcheck
+			}
+			b.Write(k)
+			b.WriteString(":")
+			b.Write(v)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("]")
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b.Bytes(), "", "  "); err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return out.String(), nil
+}
+
+// renderHTML renders the rows as an HTML table, with the footer, if any, in
+// its tfoot.
+func renderHTML(header []string, data [][]string, footer []string) string {
+	var b strings.Builder
+	row := func(cells []string, tag string) {
+		b.WriteString("    <tr>\n")
+		for _, cell := range cells {
+			cell = strings.ReplaceAll(html.EscapeString(ansi.Strip(cell)), "\n", "<br>")
+			b.WriteString("      <" + tag + ">" + cell + "</" + tag + ">\n")
+		}
+		b.WriteString("    </tr>\n")
+	}
+
+	b.WriteString("<table>\n  <thead>\n")
+	row(header, "th")
+	b.WriteString("  </thead>\n  <tbody>\n")
+	for _, cells := range data {
+		row(cells, "td")
+	}
+	b.WriteString("  </tbody>\n")
+	if footer != nil {
+		b.WriteString("  <tfoot>\n")
+		row(footer, "td")
+		b.WriteString("  </tfoot>\n")
+	}
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// renderASCII renders the table using plain ASCII characters only, which is
+// handy for places that don't render box drawing characters well. The
+// footer, if any, is set apart by a separator.
+func renderASCII(header []string, data [][]string, footer []string) string {
+	widths := columnWidths(header, data)
+	if footer != nil {
+		widths = columnWidths(header, append(slices.Clip(data), footer))
+	}
+
+	var b strings.Builder
+	separator := func() {
+		b.WriteString("+")
+		for _, w := range widths {
+			b.WriteString(strings.Repeat("-", w+2) + "+")
+		}
+		b.WriteString("\n")
+	}
+	// Cells spanning several lines make the row as tall as the tallest one.
+	line := func(cells []string) {
+		lines := make([][]string, len(widths))
+		height := 1
+		for i := range widths {
+			if i < len(cells) {
+				lines[i] = strings.Split(cells[i], "\n")
+			}
+			height = max(height, len(lines[i]))
+		}
+		for l := range height {
+			b.WriteString("|")
+			for i, w := range widths {
+				var cell string
+				if l < len(lines[i]) {
+					cell = lines[i][l]
+				}
+				b.WriteString(" " + pad(cell, w) + " |")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	separator()
+	line(header)
+	separator()
+	for _, row := range data {
+		line(row)
+	}
+	if footer != nil {
+		separator()
+		line(footer)
+	}
+	separator()
+	return b.String()
+}
@@ -0,0 +1,178 @@
+package table
+
+import (
+	"cmp"
+	"io"
+	"slices"
+	"strings"
+)
+
+// query picks the rows and columns to display, through the --where, --sort,
+// --max-rows and --select flags.
+type query struct {
+	where   []condition
+	sort    []sortKey
+	maxRows int
+	columns []int
+}
+
+// sortKey sorts the rows by a column, numerically if all of its cells are
+// numbers.
+type sortKey struct {
+	col        int
+	descending bool
+}
+
+func (o Options) query(header []string) (query, error) {
+	q := query{maxRows: o.MaxRows}
+	for _, flag := range o.Where {
+		c, err := parseCondition(header, flag)
+		if err != nil {
+			return query{}, err
+		}
+		q.where = append(q.where, c)
+	}
+
+	for _, ref := range o.Sort {
+		key := sortKey{}
+		if name, ok := strings.CutSuffix(ref, ":desc"); ok {
+			ref = name
+			key.descending = true
+		} else {
+			ref = strings.TrimSuffix(ref, ":asc")
+		}
+		i, err := columnIndex(header, ref)
+		if err != nil {
+			return query{}, err
+		}
+		key.col = i
+		q.sort = append(q.sort, key)
+	}
+
+	for _, ref := range o.Select {
+		i, err := columnIndex(header, ref)
+		if err != nil {
+			return query{}, err
+		}
+		q.columns = append(q.columns, i)
+	}
+	return q, nil
+}
+
+// empty reports whether the query keeps the data as is.
+func (q query) empty() bool {
+	return len(q.where) == 0 && len(q.sort) == 0 && q.maxRows == 0 && len(q.columns) == 0
+}
+
+// filter skips the rows not matching the --where conditions. Rows are
+// normalized first, so that conditions see all the columns.
+func (q query) filter(next rowFunc, columns int) rowFunc {
+	return func() ([]string, error) {
+		for {
+			row, err := next()
+			if err == nil {
+				row, err = normalizeRow(row, columns)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if q.keep(row) {
+				return row, nil
+			}
+		}
+	}
+}
+
+func (q query) keep(row []string) bool {
+	for _, c := range q.where {
+		if !c.matches(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply sorts, cuts and projects all the rows, when sorting.
+func (q query) apply(data [][]string) [][]string {
+	if len(q.sort) == 0 {
+		return data
+	}
+
+	numeric := make([]bool, len(q.sort))
+	for i, key := range q.sort {
+		numeric[i] = isNumericColumn(data, key.col)
+	}
+	slices.SortStableFunc(data, func(a, b []string) int {
+		for i, key := range q.sort {
+			if c := compareCells(a[key.col], b[key.col], numeric[i], key.descending); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	if q.maxRows > 0 && len(data) > q.maxRows {
+		data = data[:q.maxRows]
+	}
+	for i, row := range data {
+		data[i] = q.project(row)
+	}
+	return data
+}
+
+// rows projects the rows onto the selected columns, stopping after --max-rows
+// of them, when not sorting.
+func (q query) rows(next rowFunc) rowFunc {
+	read := 0
+	return func() ([]string, error) {
+		if q.maxRows > 0 && read >= q.maxRows {
+			return nil, io.EOF
+		}
+		row, err := next()
+		if err != nil {
+			return nil, err
+		}
+		read++
+		return q.project(row), nil
+	}
+}
+
+func (q query) project(row []string) []string {
+	if len(q.columns) == 0 {
+		return row
+	}
+	out := make([]string, 0, len(q.columns))
+	for _, i := range q.columns {
+		out = append(out, row[i])
+	}
+	return out
+}
+
+// header returns the names of the selected columns.
+func (q query) header(header []string) []string {
+	return q.project(header)
+}
+
+// compareCells compares two cells, empty ones always going last.
+func compareCells(a, b string, numeric, descending bool) int {
+	emptyA, emptyB := strings.TrimSpace(a) == "", strings.TrimSpace(b) == ""
+	if emptyA || emptyB {
+		return cmp.Compare(boolInt(emptyA), boolInt(emptyB))
+	}
+
+	c := strings.Compare(a, b)
+	if x, ok := parseNumber(a); ok && numeric {
+		y, _ := parseNumber(b)
+		c = cmp.Compare(x, y)
+	}
+	if descending {
+		return -c
+	}
+	return c
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,220 @@
+package table
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+)
+
+// cellStyle is a partial style parsed from a spec such as fg=196,bold. Only
+// the attributes it sets are applied on top of another style.
+type cellStyle []func(lipgloss.Style) lipgloss.Style
+
+func (c cellStyle) apply(s lipgloss.Style) lipgloss.Style {
+	for _, f := range c {
+		s = f(s)
+	}
+	return s
+}
+
+// parseCellStyle parses a comma separated list of fg=color, bg=color and text
+// attributes.
+func parseCellStyle(spec string) (cellStyle, error) {
+	var c cellStyle
+	for _, attr := range strings.Split(spec, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(attr), "=")
+		switch name {
+		case "fg":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Foreground(lipgloss.Color(value)) })
+		case "bg":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Background(lipgloss.Color(value)) })
+		case "bold":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Bold(true) })
+		case "faint":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Faint(true) })
+		case "italic":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Italic(true) })
+		case "underline":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Underline(true) })
+		case "strikethrough":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Strikethrough(true) })
+		case "reverse":
+			c = append(c, func(s lipgloss.Style) lipgloss.Style { return s.Reverse(true) })
+		default:
+			return nil, fmt.Errorf("invalid style %q, expected fg=color, bg=color, bold, faint, italic, underline, strikethrough or reverse", attr)
+		}
+	}
+	return c, nil
+}
+
+// condition compares a column of the rows with a value.
+type condition struct {
+	col   int
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// operators are the supported comparisons, the longer ones come first so
+// that >= isn't read as >.
+var operators = []string{"!=", ">=", "<=", "=", "~", ">", "<"}
+
+// parseCondition parses a condition in the column<op>value form.
+func parseCondition(header []string, cond string) (condition, error) {
+	var c condition
+	at := strings.IndexAny(cond, "!=~<>")
+	if at <= 0 {
+		return condition{}, fmt.Errorf("invalid condition %q, expected column=value", cond)
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(cond[at:], op) {
+			c.op = op
+			break
+		}
+	}
+	if c.op == "" {
+		return condition{}, fmt.Errorf("invalid condition %q, unknown comparison", cond)
+	}
+	c.value = cond[at+len(c.op):]
+
+	var err error
+	if c.col, err = columnIndex(header, cond[:at]); err != nil {
+		return condition{}, err
+	}
+	switch c.op {
+	case "~":
+		if c.re, err = regexp.Compile(c.value); err != nil {
+			return condition{}, fmt.Errorf("invalid condition %q: %w", cond, err)
+		}
+	case ">", ">=", "<", "<=":
+		if _, ok := parseNumber(c.value); !ok {
+			return condition{}, fmt.Errorf("invalid condition %q, %q is not a number", cond, c.value)
+		}
+	}
+	return c, nil
+}
+
+// matches reports whether the row satisfies the condition. Numbers are
+// compared by value, so 1.0 equals 1.
+func (c condition) matches(row []string) bool {
+	if c.col >= len(row) {
+		return false
+	}
+	cell := strings.TrimSpace(row[c.col])
+	switch c.op {
+	case "=":
+		return equalCells(cell, c.value)
+	case "!=":
+		return !equalCells(cell, c.value)
+	case "~":
+		return c.re.MatchString(cell)
+	}
+
+	a, ok := parseNumber(cell)
+	if !ok {
+		return false
+	}
+	b, _ := parseNumber(c.value)
+	switch c.op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	default:
+		return a <= b
+	}
+}
+
+// highlightRule styles the rows matching a condition.
+type highlightRule struct {
+	condition
+	style cellStyle
+}
+
+// parseHighlightRule parses a rule in the condition:style form.
+func parseHighlightRule(header []string, rule string) (highlightRule, error) {
+	i := strings.LastIndex(rule, ":")
+	if i < 0 {
+		return highlightRule{}, fmt.Errorf("invalid highlight %q, expected column=value:style", rule)
+	}
+	cond, err := parseCondition(header, rule[:i])
+	if err != nil {
+		return highlightRule{}, err
+	}
+	style, err := parseCellStyle(rule[i+1:])
+	if err != nil {
+		return highlightRule{}, err
+	}
+	return highlightRule{cond, style}, nil
+}
+
+func equalCells(a, b string) bool {
+	if a == b {
+		return true
+	}
+	x, ok := parseNumber(a)
+	y, ok2 := parseNumber(b)
+	return ok && ok2 && x == y
+}
+
+// styler applies the --zebra, --column-style and --highlight flags, in that
+// order, to the cells of the table.
+type styler struct {
+	zebra   cellStyle
+	columns map[int]cellStyle
+	rules   []highlightRule
+}
+
+func (o Options) styler(header []string) (styler, error) {
+	var s styler
+	if o.Zebra {
+		zebra, err := parseCellStyle(o.ZebraStyle)
+		if err != nil {
+			return styler{}, err
+		}
+		s.zebra = zebra
+	}
+
+	s.columns = make(map[int]cellStyle, len(o.ColumnStyle))
+	for _, flag := range o.ColumnStyle {
+		ref, spec, ok := strings.Cut(flag, ":")
+		if !ok {
+			return styler{}, fmt.Errorf("invalid column style %q, expected column:style", flag)
+		}
+		i, err := columnIndex(header, ref)
+		if err != nil {
+			return styler{}, err
+		}
+		if s.columns[i], err = parseCellStyle(spec); err != nil {
+			return styler{}, err
+		}
+	}
+
+	for _, flag := range o.Highlight {
+		rule, err := parseHighlightRule(header, flag)
+		if err != nil {
+			return styler{}, err
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+// style returns the style of the cell at the given column of the index-th
+// data row.
+func (s styler) style(base lipgloss.Style, index int, row []string, col int) lipgloss.Style {
+	if index%2 == 1 {
+		base = s.zebra.apply(base)
+	}
+	base = s.columns[col].apply(base)
+	for _, r := range s.rules {
+		if r.matches(row) {
+			base = r.style.apply(base)
+		}
+	}
+	return base
+}
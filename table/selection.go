@@ -0,0 +1,123 @@
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+)
+
+// Formats of the selected rows written to stdout.
+const (
+	returnCSV  = "csv"
+	returnTSV  = "tsv"
+	returnJSON = "json"
+	returnEnv  = "env"
+)
+
+// returnColumns resolves --return-column into the names and indexes of the
+// columns to return. All of them are returned by default, or when asked for
+// column 0.
+func (o Options) returnColumns(header []string) ([]string, []int, error) {
+	var names []string
+	var cols []int
+	for _, ref := range o.ReturnColumn {
+		if ref == "0" {
+			continue
+		}
+		i, err := columnIndex(header, ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, header[i])
+		cols = append(cols, i)
+	}
+	if len(cols) > 0 {
+		return names, cols, nil
+	}
+	for i := range header {
+		cols = append(cols, i)
+	}
+	return header, cols, nil
+}
+
+// formatSelection encodes the selected rows, keeping only the given columns,
+// in the --return-format. Rows are separated by --output-delimiter, except in
+// JSON where several rows make up an array.
+func (o Options) formatSelection(names []string, rows []table.Row, cols []int) (string, error) {
+	records := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		record := make([]string, 0, len(cols))
+		for _, i := range cols {
+			var cell string
+			if i < len(row) {
+				cell = row[i]
+			}
+			record = append(record, cell)
+		}
+		records = append(records, record)
+	}
+
+	if o.ReturnFormat == returnJSON {
+		out, err := renderJSON(names, records)
+		if err != nil || len(records) != 1 || o.Limit > 1 {
+			return out, err
+		}
+		// A single row is returned as an object on its own.
+		var objects []json.RawMessage
+		if err := json.Unmarshal([]byte(out), &objects); err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		var obj bytes.Buffer
+		if err := json.Indent(&obj, objects[0], "", "  "); err != nil {
+			return "", err //nolint:wrapcheck
+		}
+		return obj.String(), nil
+	}
+
+	out := make([]string, 0, len(records))
+	for _, record := range records {
+		var s string
+		switch o.ReturnFormat {
+		case returnTSV:
+			s = strings.TrimSuffix(renderTSV(record, nil), "\n")
+		case returnEnv:
+			s = renderEnv(names, record)
+		default:
+			var b strings.Builder
+			writer := csv.NewWriter(&b)
+			writer.Comma = []rune(o.Separator)[0]
+			if err := writer.Write(record); err != nil {
+				return "", err //nolint:wrapcheck
+			}
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return "", err //nolint:wrapcheck
+			}
+			s = strings.TrimSuffix(b.String(), "\n")
+		}
+		out = append(out, s)
+	}
+	return strings.Join(out, o.OutputDelimiter), nil
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// renderEnv writes the record as NAME=value lines meant to be evaluated by a
+// shell. Names are turned into upper case identifiers and values are single
+// quoted.
+func renderEnv(names []string, record []string) string {
+	lines := make([]string, 0, len(names))
+	for i, name := range names {
+		name = strings.ToUpper(nonIdentifier.ReplaceAllString(name, "_"))
+		if name == "" || name[0] >= '0' && name[0] <= '9' {
+			name = "_" + name
+		}
+		value := "'" + strings.ReplaceAll(record[i], "'", `'\''`) + "'"
+		lines = append(lines, name+"="+value)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -15,12 +15,20 @@
 package table
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/textinput"
+	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 )
@@ -28,6 +36,24 @@ import (
 type keymap struct {
 	Navigate,
 	Select,
+	Toggle,
+	ToggleAll,
+	Left,
+	Right,
+	NextCell,
+	PrevCell,
+	Edit,
+	ConfirmEdit,
+	CancelEdit,
+	Save,
+	Detail,
+	DetailUp,
+	DetailDown,
+	Columns,
+	ToggleColumn,
+	MoveColumnUp,
+	MoveColumnDown,
+	CloseChooser,
 	Quit,
 	Abort key.Binding
 }
@@ -38,8 +64,14 @@ func (k keymap) FullHelp() [][]key.Binding { return nil }
 // ShortHelp implements help.KeyMap.
 func (k keymap) ShortHelp() []key.Binding {
 	return []key.Binding{
+		k.Toggle,
 		k.Navigate,
 		k.Select,
+		k.ToggleAll,
+		k.Edit,
+		k.Save,
+		k.Detail,
+		k.Columns,
 		k.Quit,
 	}
 }
@@ -48,12 +80,84 @@ func defaultKeymap() keymap {
 	return keymap{
 		Navigate: key.NewBinding(
 			key.WithKeys("up", "down"),
-			key.WithHelp("↓↑", "navigate"),
+			key.WithHelp("←↓↑→", "navigate"),
 		),
 		Select: key.NewBinding(
 			key.WithKeys("enter"),
 			key.WithHelp("enter", "select"),
 		),
+		Toggle: key.NewBinding(
+			key.WithKeys("space", "tab", "x", "ctrl+@"),
+			key.WithHelp("x", "toggle"),
+			key.WithDisabled(),
+		),
+		ToggleAll: key.NewBinding(
+			key.WithKeys("a", "A", "ctrl+a"),
+			key.WithHelp("ctrl+a", "select all"),
+			key.WithDisabled(),
+		),
+		Left: key.NewBinding(
+			key.WithKeys("left", "h"),
+		),
+		Right: key.NewBinding(
+			key.WithKeys("right", "l"),
+		),
+		NextCell: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithDisabled(),
+		),
+		PrevCell: key.NewBinding(
+			key.WithKeys("shift+tab"),
+			key.WithDisabled(),
+		),
+		Edit: key.NewBinding(
+			key.WithKeys("enter", "e"),
+			key.WithHelp("e", "edit"),
+			key.WithDisabled(),
+		),
+		ConfirmEdit: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "confirm"),
+		),
+		CancelEdit: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+		Save: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save"),
+			key.WithDisabled(),
+		),
+		Detail: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "details"),
+		),
+		DetailUp: key.NewBinding(
+			key.WithKeys("K", "shift+up"),
+		),
+		DetailDown: key.NewBinding(
+			key.WithKeys("J", "shift+down"),
+		),
+		Columns: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "columns"),
+		),
+		ToggleColumn: key.NewBinding(
+			key.WithKeys("space", "x"),
+			key.WithHelp("x", "show/hide"),
+		),
+		MoveColumnUp: key.NewBinding(
+			key.WithKeys("K", "shift+up"),
+			key.WithHelp("K", "move up"),
+		),
+		MoveColumnDown: key.NewBinding(
+			key.WithKeys("J", "shift+down"),
+			key.WithHelp("J", "move down"),
+		),
+		CloseChooser: key.NewBinding(
+			key.WithKeys("esc", "enter", "c"),
+			key.WithHelp("esc", "close"),
+		),
 		Quit: key.NewBinding(
 			key.WithKeys("esc", "ctrl+q", "q"),
 			key.WithHelp("esc", "quit"),
@@ -67,60 +171,460 @@ func defaultKeymap() keymap {
 
 type model struct {
 	table     table.Model
+	rows      []table.Row
 	selected  table.Row
 	quitting  bool
+	submitted bool
 	showHelp  bool
 	hideCount bool
 	help      help.Model
 	keymap    keymap
 	padding   []int
+
+	// Only the rows around the cursor are handed to the table widget, the
+	// model keeps track of the actual cursor position.
+	cursor    int
+	offset    int
+	formats   []columnFormat
+	widths    []int
+	thousands bool
+	styler    styler
+	cellStyle lipgloss.Style
+
+	// Rows span as many lines as their tallest cell.
+	maxRowHeight  int
+	continuation  string
+	selectedStyle lipgloss.Style
+
+	// The footer shows aggregates of the columns below the rows.
+	footerFuncs map[int]string
+	totals      totals
+	footer      []string
+	footerStyle lipgloss.Style
+
+	// Likewise only the columns fitting the terminal are handed to the table
+	// widget.
+	columns     []table.Column
+	header      []string
+	natural     []int
+	mins        []int
+	weights     []int
+	fit         bool
+	visible     []int
+	gutter      int
+	freeze      int
+	colOffset   int
+	width       int
+	cellFrame   int
+	hiddenLeft  bool
+	hiddenRight bool
+
+	// The columns can be hidden and reordered through the column chooser.
+	order         []int
+	hidden        map[int]bool
+	choosing      bool
+	chooserCursor int
+
+	// The detail pane lists all the fields of the highlighted row.
+	detail         bool
+	detailPosition string
+	detailPane     viewport.Model
+	detailRow      int
+	detailKeyStyle lipgloss.Style
+
+	// editing
+	editable    bool
+	col         int
+	editing     bool
+	input       textinput.Model
+	edited      map[[2]int]bool
+	readonly    map[int]bool
+	validators  map[int]*regexp.Regexp
+	editErr     string
+	activeStyle lipgloss.Style
+	editedStyle lipgloss.Style
+	errorStyle  lipgloss.Style
+
+	// streaming
+	loading bool
+	next    rowFunc
+	err     error
+
+	// multiple selection
+	limit            int
+	marks            map[int]int
+	currentOrder     int
+	selectedPrefix   string
+	unselectedPrefix string
+}
+
+// rowsMsg carries a batch of rows read in the background.
+type rowsMsg struct {
+	rows []table.Row
+	done bool
+	err  error
+}
+
+const (
+	batchSize  = 1000
+	batchDelay = 50 * time.Millisecond
+)
+
+// loadRows reads the next batch of rows from the input.
+func (m model) loadRows() tea.Cmd {
+	next := m.next
+	columns := len(m.formats)
+	return func() tea.Msg {
+		var msg rowsMsg
+		deadline := time.Now().Add(batchDelay)
+		for len(msg.rows) < batchSize && time.Now().Before(deadline) {
+			row, err := next()
+			if errors.Is(err, io.EOF) {
+				msg.done = true
+				return msg
+			}
+			if err == nil {
+				row, err = normalizeRow(row, columns)
+			}
+			if err != nil {
+				msg.done = true
+				msg.err = err
+				return msg
+			}
+			msg.rows = append(msg.rows, row)
+		}
+		return msg
+	}
 }
 
-func (m model) Init() tea.Cmd { return nil }
+func (m model) Init() tea.Cmd {
+	if m.loading {
+		return m.loadRows()
+	}
+	return nil
+}
 
 func (m model) countView() string {
 	if m.hideCount {
 		return ""
 	}
 
-	padding := strconv.Itoa(numLen(len(m.table.Rows())))
+	var status string
+	switch {
+	case m.err != nil:
+		status = " (" + m.err.Error() + ")"
+	case m.loading:
+		status = "…"
+	}
+
+	padding := strconv.Itoa(numLen(len(m.rows)))
 	return m.help.Styles.FullDesc.Render(fmt.Sprintf(
-		"%"+padding+"d/%d%s",
-		m.table.Cursor()+1,
-		len(m.table.Rows()),
+		"%"+padding+"d/%d%s%s",
+		m.cursor+1,
+		len(m.rows),
+		status,
 		m.help.ShortSeparator,
 	))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.layout()
+		return m, nil
+	case rowsMsg:
+		m.rows = append(m.rows, msg.rows...)
+		m.addToFooter(msg.rows)
+		m.sync()
+		if msg.done {
+			m.loading = false
+			m.err = msg.err
+			return m, nil
+		}
+		return m, m.loadRows()
 	case tea.KeyPressMsg:
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		if m.choosing {
+			return m.updateChooser(msg)
+		}
+
 		km := m.keymap
+		tkm := m.table.KeyMap
+		height := m.table.Height()
+		m.editErr = ""
 		switch {
+		case key.Matches(msg, km.Edit):
+			cmd := m.startEditing()
+			m.sync()
+			return m, cmd
+		case key.Matches(msg, km.Save):
+			m.quitting = true
+			m.submitted = true
+			return m, tea.Quit
+		case key.Matches(msg, km.Left) && m.editable:
+			m.moveCell(-1, false)
+		case key.Matches(msg, km.Right) && m.editable:
+			m.moveCell(1, false)
+		case key.Matches(msg, km.Left):
+			m.scroll(-1)
+		case key.Matches(msg, km.Right):
+			m.scroll(1)
+		case key.Matches(msg, km.PrevCell):
+			m.moveCell(-1, true)
+		case key.Matches(msg, km.NextCell):
+			m.moveCell(1, true)
 		case key.Matches(msg, km.Select):
-			m.selected = m.table.SelectedRow()
+			if m.limit > 1 && len(m.marks) == 0 {
+				m.toggle(m.cursor)
+			}
+			if m.cursor >= 0 && m.cursor < len(m.rows) {
+				m.selected = m.rows[m.cursor]
+			}
 			m.quitting = true
+			m.submitted = true
 			return m, tea.Quit
+		case key.Matches(msg, km.Toggle):
+			m.toggle(m.cursor)
+		case key.Matches(msg, km.ToggleAll):
+			if len(m.marks) < len(m.rows) && len(m.marks) < m.limit {
+				m.selectAll()
+			} else {
+				m.deselectAll()
+			}
+		case key.Matches(msg, km.Detail):
+			m.detail = !m.detail
+			m.layout()
+		case key.Matches(msg, km.DetailUp):
+			m.detailPane.ScrollUp(1)
+		case key.Matches(msg, km.DetailDown):
+			m.detailPane.ScrollDown(1)
+		case key.Matches(msg, km.Columns):
+			m.choosing = true
 		case key.Matches(msg, km.Quit):
 			m.quitting = true
 			return m, tea.Quit
 		case key.Matches(msg, km.Abort):
 			m.quitting = true
 			return m, tea.Interrupt
+		case key.Matches(msg, tkm.LineUp):
+			m.cursor--
+		case key.Matches(msg, tkm.LineDown):
+			m.cursor++
+		case key.Matches(msg, tkm.PageUp):
+			m.cursor -= height
+		case key.Matches(msg, tkm.PageDown):
+			m.cursor += height
+		case key.Matches(msg, tkm.HalfPageUp):
+			m.cursor -= height / 2
+		case key.Matches(msg, tkm.HalfPageDown):
+			m.cursor += height / 2
+		case key.Matches(msg, tkm.GotoTop):
+			m.cursor = 0
+		case key.Matches(msg, tkm.GotoBottom):
+			m.cursor = len(m.rows) - 1
 		}
+		m.sync()
+		return m, nil
 	}
 
+	var cmd tea.Cmd
 	m.table, cmd = m.table.Update(msg)
 	return m, cmd
 }
 
+// sync scrolls to keep the cursor in view and hands the visible rows to the
+// table widget.
+func (m *model) sync() {
+	height := max(m.table.Height(), 1)
+	m.cursor = max(min(m.cursor, len(m.rows)-1), 0)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	// Rows can span several lines, scroll until the whole row under the
+	// cursor is in view, without leaving room at the bottom.
+	for m.offset < m.cursor && !m.fits(m.offset, m.cursor+1, height) {
+		m.offset++
+	}
+	for m.offset > 0 && m.fits(m.offset-1, len(m.rows), height) {
+		m.offset--
+	}
+
+	rows, cursor := m.visibleRows(height)
+	m.table.SetRows(rows)
+	m.table.SetCursor(cursor)
+	m.refreshDetail()
+}
+
+// fits reports whether the rows from start to end fit in height lines.
+func (m model) fits(start, end, height int) bool {
+	lines := 0
+	for i := start; i < end; i++ {
+		lines += m.rowHeight(i)
+		if lines > height {
+			return false
+		}
+	}
+	return true
+}
+
+// rowHeight returns the number of lines taken by a row, which is the number
+// of lines of its tallest visible cell, up to --max-row-height.
+func (m model) rowHeight(i int) int {
+	height := 1
+	for _, c := range m.visible {
+		height = max(height, strings.Count(m.rows[i][c], "\n")+1)
+	}
+	if m.maxRowHeight > 0 {
+		height = min(height, m.maxRowHeight)
+	}
+	return height
+}
+
+// visibleRows returns the formatted rows currently in view, with the selection
+// marker prepended to each of them if multiple rows can be picked. Rows
+// spanning several lines are handed to the table widget line by line, so the
+// index of the line under the cursor is returned too.
+func (m model) visibleRows(height int) ([]table.Row, int) {
+	var rows []table.Row
+	cursor := 0
+	for i := m.offset; i < len(m.rows) && len(rows) < height; i++ {
+		if i == m.cursor {
+			cursor = len(rows)
+		}
+		h := m.rowHeight(i)
+		cells := make([][]string, 0, len(m.visible))
+		for _, c := range m.visible {
+			cells = append(cells, m.renderCell(i, c, m.rows[i][c], h))
+		}
+
+		for line := 0; line < h && len(rows) < height; line++ {
+			row := make(table.Row, 0, len(cells)+1)
+			if m.gutter > 0 {
+				var prefix string
+				if _, ok := m.marks[i]; ok && line == 0 {
+					prefix = m.selectedPrefix
+				} else if line == 0 {
+					prefix = m.unselectedPrefix
+				}
+				row = append(row, prefix)
+			}
+			for _, lines := range cells {
+				row = append(row, lines[line])
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, cursor
+}
+
+// renderCell formats and aligns a single cell, returning its lines.
+func (m model) renderCell(row, col int, cell string, height int) []string {
+	f := m.formats[col]
+	lines := make([]string, height)
+	value := clampLines(f.value(cell, m.thousands), height, m.continuation, m.widths[col])
+	copy(lines, strings.Split(value, "\n"))
+	if m.editing && row == m.cursor && col == m.col {
+		lines[0] = m.input.View()
+		for i := 1; i < height; i++ {
+			lines[i] = alignCell("", m.widths[col], f.align)
+		}
+		return lines
+	}
+
+	s := m.styler.style(lipgloss.NewStyle(), row, m.rows[row], col)
+	active := m.editable && row == m.cursor && col == m.col
+	switch {
+	case active:
+		s = m.activeStyle
+	case m.edited[[2]int{row, col}]:
+		s = m.editedStyle
+	}
+	// The colours of the cell would reset the ones of the row under the
+	// cursor, so the selected style is layered over them.
+	if row == m.cursor && !active {
+		s = m.selectedStyle.Inherit(s)
+	}
+	for i, line := range lines {
+		lines[i] = s.Render(alignCell(line, m.widths[col], f.align))
+	}
+	return lines
+}
+
+// toggle marks or unmarks the row at the given index, respecting the limit.
+func (m *model) toggle(i int) {
+	if i < 0 || i >= len(m.rows) {
+		return
+	}
+	if _, ok := m.marks[i]; ok {
+		delete(m.marks, i)
+		return
+	}
+	if len(m.marks) >= m.limit {
+		return
+	}
+	m.marks[i] = m.currentOrder
+	m.currentOrder++
+}
+
+func (m *model) selectAll() {
+	for i := range m.rows {
+		if len(m.marks) >= m.limit {
+			break // do not exceed given limit
+		}
+		if _, ok := m.marks[i]; ok {
+			continue
+		}
+		m.marks[i] = m.currentOrder
+		m.currentOrder++
+	}
+}
+
+func (m *model) deselectAll() {
+	m.marks = map[int]int{}
+	m.currentOrder = 0
+}
+
+// selection returns the marked rows, either in the order they appear in the
+// table or in the order they were picked.
+func (m model) selection(ordered bool) []table.Row {
+	indexes := make([]int, 0, len(m.marks))
+	for i := range m.marks {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(a, b int) bool {
+		if ordered {
+			return m.marks[indexes[a]] < m.marks[indexes[b]]
+		}
+		return indexes[a] < indexes[b]
+	})
+	rows := make([]table.Row, 0, len(indexes))
+	for _, i := range indexes {
+		rows = append(rows, m.rows[i])
+	}
+	return rows
+}
+
 func (m model) View() tea.View {
 	if m.quitting {
 		return tea.NewView("")
 	}
-	s := m.table.View()
+	t := m.table.View()
+	if m.footer != nil {
+		t += "\n" + m.footerView()
+	}
+	s := m.detailView(m.scrollHints(t))
+	if m.choosing {
+		s = lipgloss.NewCompositor(
+			lipgloss.NewLayer(s),
+			lipgloss.NewLayer(m.chooserView()).X(2).Y(1).Z(1),
+		).Render()
+	}
+	if m.editErr != "" {
+		s += "\n" + m.errorStyle.Render(m.editErr)
+	}
 	if m.showHelp {
 		s += "\n" + m.countView() + m.help.View(m.keymap)
 	}
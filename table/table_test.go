@@ -0,0 +1,800 @@
+package table
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+func TestReadTable(t *testing.T) {
+	for name, tt := range map[string]struct {
+		in      string
+		format  string
+		columns []string
+		header  []string
+		data    [][]string
+	}{
+		"csv": {
+			in:     "a,b\n1,2\n",
+			header: []string{"a", "b"},
+			data:   [][]string{{"1", "2"}},
+		},
+		"tsv": {
+			in:     "a\tb\n1\t2\n",
+			header: []string{"a", "b"},
+			data:   [][]string{{"1", "2"}},
+		},
+		"json objects": {
+			in:     `[{"name":"a","meta":{"x":1}},{"name":"b","tags":["q"],"meta":{"x":null}}]`,
+			header: []string{"name", "meta.x", "tags"},
+			data:   [][]string{{"a", "1", ""}, {"b", "", `["q"]`}},
+		},
+		"json arrays": {
+			in:     `[["a","b"],[1,true]]`,
+			header: []string{"a", "b"},
+			data:   [][]string{{"1", "true"}},
+		},
+		"jsonl with columns": {
+			in:      "{\"a\":1,\"b\":2}\n{\"a\":3,\"b\":4}\n",
+			columns: []string{"b", "a"},
+			header:  []string{"b", "a"},
+			data:    [][]string{{"2", "1"}, {"4", "3"}},
+		},
+		"markdown": {
+			in:     "| a | b |\n|---|:-:|\n| 1 | x\\|y |\n",
+			header: []string{"a", "b"},
+			data:   [][]string{{"1", "x|y"}},
+		},
+		"explicit csv": {
+			in:     "[a],b\n1,2\n",
+			format: formatCSV,
+			header: []string{"[a]", "b"},
+			data:   [][]string{{"1", "2"}},
+		},
+		"csv header like an array": {
+			in:     "[id],name\n1,a\n",
+			header: []string{"[id]", "name"},
+			data:   [][]string{{"1", "a"}},
+		},
+		"csv header like an object": {
+			in:     "{id},name\n1,a\n",
+			header: []string{"{id}", "name"},
+			data:   [][]string{{"1", "a"}},
+		},
+		"indented json": {
+			in:     "[\n  {\n    \"a\": 1\n  }\n]\n",
+			header: []string{"a"},
+			data:   [][]string{{"1"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			o := Options{Separator: ",", InputFormat: formatAuto, Columns: tt.columns}
+			if tt.format != "" {
+				o.InputFormat = tt.format
+			}
+			header, next, err := o.openTable(strings.NewReader(tt.in), -1, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var data [][]string
+			for {
+				row, err := next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				data = append(data, row)
+			}
+			if !reflect.DeepEqual(header, tt.header) {
+				t.Errorf("expected header %q, got %q", tt.header, header)
+			}
+			if !reflect.DeepEqual(data, tt.data) {
+				t.Errorf("expected data %q, got %q", tt.data, data)
+			}
+		})
+	}
+}
+
+func TestReadTableNoColumns(t *testing.T) {
+	for name, in := range map[string]string{
+		"empty object": "[{}]",
+		"empty array":  "[[]]",
+		"empty jsonl":  "{}\n{}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			o := Options{Separator: ",", InputFormat: formatAuto}
+			if _, _, err := o.openTable(strings.NewReader(in), -1, nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRenderOutput(t *testing.T) {
+	header := []string{"name", "note"}
+	data := [][]string{{"a", "x|y"}, {"b", `say "hi"`}}
+
+	expect := "| name | note     |\n" +
+		"| ---- | -------- |\n" +
+		"| a    | x\\|y     |\n" +
+		"| b    | say \"hi\" |\n"
+	if got := renderMarkdown(header, data); got != expect {
+		t.Errorf("expected markdown %q, got %q", expect, got)
+	}
+
+	expect = "[\n" +
+		"  {\n    \"name\": \"a\",\n    \"note\": \"x|y\"\n  },\n" +
+		"  {\n    \"name\": \"b\",\n    \"note\": \"say \\\"hi\\\"\"\n  }\n" +
+		"]"
+	got, err := renderJSON(header, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != expect {
+		t.Errorf("expected json %q, got %q", expect, got)
+	}
+
+	header, data = []string{"n"}, [][]string{{"1"}, {"22"}}
+	footer := []string{"sum 23"}
+	expect = "+--------+\n| n      |\n+--------+\n| 1      |\n| 22     |\n+--------+\n| sum 23 |\n+--------+\n"
+	if got := renderASCII(header, data, footer); got != expect {
+		t.Errorf("expected ascii %q, got %q", expect, got)
+	}
+	expect = "  <tfoot>\n    <tr>\n      <td>sum 23</td>\n    </tr>\n  </tfoot>\n</table>\n"
+	if got := renderHTML(header, data, footer); !strings.HasSuffix(got, expect) {
+		t.Errorf("expected html ending with %q, got %q", expect, got)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for name, tt := range map[string]struct {
+		format    columnFormat
+		thousands bool
+		in        string
+		out       string
+	}{
+		"untouched":     {format: columnFormat{numeric: true}, in: "1234.5", out: "1234.5"},
+		"text":          {format: columnFormat{verb: "%.2f"}, in: "n/a", out: "n/a"},
+		"decimals":      {format: columnFormat{numeric: true, verb: "%.2f"}, in: "3.14159", out: "3.14"},
+		"integer":       {format: columnFormat{numeric: true, verb: "%05d"}, in: "42", out: "00042"},
+		"thousands":     {format: columnFormat{numeric: true}, thousands: true, in: "-1234567.891", out: "-1,234,567.891"},
+		"small":         {format: columnFormat{numeric: true}, thousands: true, in: "999", out: "999"},
+		"both":          {format: columnFormat{numeric: true, verb: "%.1f"}, thousands: true, in: "1000", out: "1,000.0"},
+		"not numeric":   {format: columnFormat{}, thousands: true, in: "1000", out: "1000"},
+		"verb and text": {format: columnFormat{verb: "%.1f"}, in: "7", out: "7.0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := tt.format.value(tt.in, tt.thousands); got != tt.out {
+				t.Errorf("expected %q, got %q", tt.out, got)
+			}
+		})
+	}
+}
+
+func TestFormatVerb(t *testing.T) {
+	header := []string{"a"}
+	data := [][]string{{"1"}}
+	for verb, valid := range map[string]bool{
+		"%d":     true,
+		"%05d":   true,
+		"%.2f":   true,
+		"%+8.3e": true,
+		"%g":     true,
+		"%s":     false,
+		"%v":     false,
+		"%x":     false,
+		"%.2f%":  false,
+		"$%.2f":  false,
+		"%":      false,
+	} {
+		_, err := Options{Format: []string{"a=" + verb}}.columnFormats(header, data)
+		if valid && err != nil {
+			t.Errorf("unexpected error for %q: %v", verb, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("expected an error for %q", verb)
+		}
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	for name, tt := range map[string]struct {
+		in      string
+		format  string
+		columns []string
+		edits   map[[2]int]string
+		out     string
+	}{
+		"unchanged": {
+			in:     `{"id":"1","n":2,"ok":null,"meta":{},"tags":["x"]}` + "\n",
+			format: formatJSONL,
+			out:    `{"id":"1","n":2,"ok":null,"meta":{},"tags":["x"]}` + "\n",
+		},
+		"types kept": {
+			in:     `{"id":"1","n":2,"ok":true,"note":null}` + "\n" + `{"id":"2","n":3,"ok":false,"note":"a"}` + "\n",
+			format: formatJSONL,
+			edits:  map[[2]int]string{{0, 0}: "7", {0, 1}: "5", {0, 2}: "false", {1, 1}: "many", {1, 2}: "", {1, 3}: "b"},
+			out:    `{"id":"7","n":5,"ok":false,"note":null}` + "\n" + `{"id":"2","n":"many","ok":null,"note":"b"}` + "\n",
+		},
+		"nested": {
+			in:     `{"a":{"b":1,"c":"x"},"d.e":"y"}` + "\n",
+			format: formatJSONL,
+			edits:  map[[2]int]string{{0, 1}: "z", {0, 2}: "w"},
+			out:    `{"a":{"b":1,"c":"z"},"d.e":"w"}` + "\n",
+		},
+		"hidden keys kept": {
+			in:      `{"id":1,"secret":"s"}` + "\n",
+			format:  formatJSONL,
+			columns: []string{"id", "extra"},
+			edits:   map[[2]int]string{{0, 1}: "new"},
+			out:     `{"id":1,"secret":"s","extra":"new"}` + "\n",
+		},
+		"arrays": {
+			in:     `[["id","n"],["a",1]]`,
+			format: formatJSON,
+			edits:  map[[2]int]string{{0, 1}: "2"},
+			out:    "[\n  [\n    \"id\",\n    \"n\"\n  ],\n  [\n    \"a\",\n    2\n  ]\n]",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			o := Options{InputFormat: tt.format, Columns: tt.columns}
+			values := &jsonValues{}
+			header, next, err := o.openTable(strings.NewReader(tt.in), -1, values)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var data [][]string
+			for {
+				row, err := next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				data = append(data, row)
+			}
+			edited := map[[2]int]bool{}
+			for cell, value := range tt.edits {
+				data[cell[0]][cell[1]] = value
+				edited[cell] = true
+			}
+			got, err := encodeJSON(header, data, values, edited, tt.format == formatJSONL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.out {
+				t.Errorf("expected %q, got %q", tt.out, got)
+			}
+		})
+	}
+}
+
+func TestShareWidths(t *testing.T) {
+	for name, tt := range map[string]struct {
+		widths, mins, weights []int
+		available             int
+		out                   []int
+	}{
+		"fits":       {widths: []int{5, 10}, mins: []int{5, 5}, weights: []int{1, 1}, available: 20, out: []int{5, 10}},
+		"widest":     {widths: []int{8, 30}, mins: []int{5, 5}, weights: []int{1, 1}, available: 30, out: []int{8, 20}},
+		"both":       {widths: []int{10, 12}, mins: []int{5, 5}, weights: []int{1, 1}, available: 20, out: []int{9, 9}},
+		"fixed":      {widths: []int{20, 10}, mins: []int{5, 5}, weights: []int{0, 1}, available: 28, out: []int{20, 6}},
+		"weighted":   {widths: []int{15, 15}, mins: []int{5, 5}, weights: []int{1, 3}, available: 26, out: []int{15, 9}},
+		"too narrow": {widths: []int{10, 10}, mins: []int{6, 6}, weights: []int{1, 1}, available: 4, out: []int{6, 6}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := shareWidths(tt.widths, tt.mins, tt.weights, 1, tt.available)
+			if !reflect.DeepEqual(got, tt.out) {
+				t.Errorf("expected %v, got %v", tt.out, got)
+			}
+		})
+	}
+}
+
+func TestHighlightRule(t *testing.T) {
+	header := []string{"name", "status", "cost"}
+	row := []string{"alpha", "FAILED", "12.0"}
+	for rule, match := range map[string]bool{
+		"status=FAILED:fg=196,bold": true,
+		"status!=FAILED:bold":       false,
+		"name~^al:bold":             true,
+		"cost=12:bold":              true,
+		"cost>12:bold":              false,
+		"cost>=12:bold":             true,
+		"3<20:bold":                 true,
+		"name>1:bold":               false,
+	} {
+		r, err := parseHighlightRule(header, rule)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", rule, err)
+		}
+		if got := r.matches(row); got != match {
+			t.Errorf("expected %q to match %v, got %v", rule, match, got)
+		}
+	}
+
+	for _, rule := range []string{"status=FAILED", "unknown=1:bold", "cost>x:bold", "status=OK:blink"} {
+		if _, err := parseHighlightRule(header, rule); err == nil {
+			t.Errorf("expected an error for %q", rule)
+		}
+	}
+}
+
+func TestRenderEnv(t *testing.T) {
+	got := renderEnv([]string{"name", "full-name", "2nd"}, []string{"a", "it's", ""})
+	expect := "NAME='a'\nFULL_NAME='it'\\''s'\n_2ND=''"
+	if got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	header := []string{"name", "status", "age"}
+	rows := [][]string{
+		{"a", "ok", "5"},
+		{"b", "failed", "40"},
+		{"c", "warn", "100"},
+		{"d", "failed"},
+	}
+	o := Options{
+		Where:  []string{"status!=ok"},
+		Sort:   []string{"age:desc"},
+		Select: []string{"age", "name"},
+	}
+	q, err := o.query(header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	next := q.filter(func() ([]string, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}, len(header))
+	var data [][]string
+	for {
+		row, err := next()
+		if err != nil {
+			break
+		}
+		data = append(data, row)
+	}
+
+	expect := [][]string{{"100", "c"}, {"40", "b"}, {"", "d"}}
+	if got := q.apply(data); !reflect.DeepEqual(got, expect) {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+	if got := q.header(header); !reflect.DeepEqual(got, []string{"age", "name"}) {
+		t.Errorf("unexpected header %q", got)
+	}
+}
+
+func TestFooterRow(t *testing.T) {
+	rows := [][]string{{"a", "1.5", "x"}, {"b", "2.25", ""}, {"a", "n/a", "x"}}
+	formats := []columnFormat{{}, {numeric: true}, {}}
+	footer := func(funcs map[int]string, batches ...[][]string) []string {
+		totals := newTotals(funcs)
+		for _, batch := range batches {
+			totals.add(batch...)
+		}
+		return totals.row(formats, false)
+	}
+	for fn, expect := range map[string]string{
+		"sum":      "sum 3.75",
+		"avg":      "avg 1.875",
+		"min":      "min 1.5",
+		"max":      "max 2.25",
+		"count":    "count 3",
+		"distinct": "distinct 3",
+	} {
+		got := footer(map[int]string{1: fn}, rows)
+		if got[1] != expect || got[0] != "" {
+			t.Errorf("expected %q for %s, got %q", expect, fn, got)
+		}
+		// Rows read in batches add up to the same.
+		if got := footer(map[int]string{1: fn}, rows[:1], rows[1:2], rows[2:]); got[1] != expect {
+			t.Errorf("expected %q for %s in batches, got %q", expect, fn, got[1])
+		}
+	}
+	if got := footer(map[int]string{2: "count"}, rows); got[2] != "count 2" {
+		t.Errorf("expected empty cells not to be counted, got %q", got[2])
+	}
+	if got := footer(map[int]string{0: "distinct"}, rows[:2], rows[2:]); got[0] != "distinct 2" {
+		t.Errorf("expected values seen in earlier batches not to be counted again, got %q", got[0])
+	}
+	if got := footer(nil, rows); got != nil {
+		t.Errorf("expected no footer, got %q", got)
+	}
+}
+
+func TestClampLines(t *testing.T) {
+	for name, tt := range map[string]struct {
+		cell   string
+		height int
+		width  int
+		out    string
+	}{
+		"short":     {cell: "a\nb", height: 2, out: "a\nb"},
+		"unlimited": {cell: "a\nb\nc", height: 0, out: "a\nb\nc"},
+		"cut":       {cell: "a\nb\nc", height: 2, out: "a\nb…"},
+		"narrow":    {cell: "a\nbcd\ne", height: 2, width: 3, out: "a\nbc…"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := clampLines(tt.cell, tt.height, "…", tt.width); got != tt.out {
+				t.Errorf("expected %q, got %q", tt.out, got)
+			}
+		})
+	}
+}
+
+func TestSelection(t *testing.T) {
+	rows := []table.Row{{"a"}, {"b"}, {"c"}, {"d"}}
+	m := model{rows: rows, marks: map[int]int{}, limit: 2}
+
+	m.toggle(2)
+	m.toggle(0)
+	m.toggle(1)
+	if len(m.marks) != 2 {
+		t.Fatalf("expected the limit to stop at 2 rows, got %d", len(m.marks))
+	}
+	if got := m.selection(false); !reflect.DeepEqual(got, []table.Row{{"a"}, {"c"}}) {
+		t.Errorf("expected the rows in table order, got %q", got)
+	}
+	if got := m.selection(true); !reflect.DeepEqual(got, []table.Row{{"c"}, {"a"}}) {
+		t.Errorf("expected the rows in the order they were picked, got %q", got)
+	}
+
+	m.toggle(2)
+	if got := m.selection(false); !reflect.DeepEqual(got, []table.Row{{"a"}}) {
+		t.Errorf("expected toggling again to unmark the row, got %q", got)
+	}
+
+	m.deselectAll()
+	m.limit = 3
+	m.selectAll()
+	if got := m.selection(false); !reflect.DeepEqual(got, []table.Row{{"a"}, {"b"}, {"c"}}) {
+		t.Errorf("expected select all to stop at the limit, got %q", got)
+	}
+}
+
+func TestSelectWithoutMarks(t *testing.T) {
+	m := model{
+		rows:   []table.Row{{"a"}, {"b"}},
+		marks:  map[int]int{},
+		limit:  2,
+		cursor: 1,
+		keymap: defaultKeymap(),
+	}
+	tm, _ := m.Update(tea.KeyPressMsg{Code: tea.KeyEnter})
+	m = tm.(model)
+	if !m.submitted {
+		t.Fatal("expected enter to submit")
+	}
+	if got := m.selection(false); !reflect.DeepEqual(got, []table.Row{{"b"}}) {
+		t.Errorf("expected the row under the cursor to be picked, got %q", got)
+	}
+}
+
+func TestLoadRows(t *testing.T) {
+	n := 0
+	m := model{
+		formats: make([]columnFormat, 2),
+		next: func() ([]string, error) {
+			if n == batchSize+10 {
+				return nil, io.EOF
+			}
+			n++
+			return []string{fmt.Sprint(n)}, nil
+		},
+		marks: map[int]int{},
+		table: table.New(),
+	}
+
+	msg := m.loadRows()().(rowsMsg)
+	if len(msg.rows) != batchSize || msg.done {
+		t.Fatalf("expected a full batch of %d rows, got %d (done: %v)", batchSize, len(msg.rows), msg.done)
+	}
+	if !reflect.DeepEqual(msg.rows[0], table.Row{"1", ""}) {
+		t.Errorf("expected rows to be filled up to the number of columns, got %q", msg.rows[0])
+	}
+
+	m.loading = true
+	tm, cmd := m.Update(msg)
+	m = tm.(model)
+	if len(m.rows) != batchSize || !m.loading || cmd == nil {
+		t.Fatalf("expected the batch to be appended and the next one loaded, got %d rows", len(m.rows))
+	}
+
+	msg = cmd().(rowsMsg)
+	if len(msg.rows) != 10 || !msg.done {
+		t.Fatalf("expected the last 10 rows, got %d (done: %v)", len(msg.rows), msg.done)
+	}
+	tm, cmd = m.Update(msg)
+	m = tm.(model)
+	if len(m.rows) != batchSize+10 || m.loading || cmd != nil {
+		t.Errorf("expected loading to stop with all the rows, got %d rows", len(m.rows))
+	}
+
+	m.next = func() ([]string, error) { return []string{"a", "b", "c"}, nil }
+	msg = m.loadRows()().(rowsMsg)
+	if !msg.done || msg.err == nil {
+		t.Fatalf("expected rows with too many cells to stop loading with an error")
+	}
+	tm, _ = m.Update(msg)
+	if tm.(model).err == nil {
+		t.Error("expected the error to be kept")
+	}
+}
+
+// layoutModel returns a model with columns of the given widths, each taking 2
+// extra cells, in a terminal of the given width.
+func layoutModel(width int, widths ...int) model {
+	n := len(widths)
+	m := model{
+		width:     width,
+		padding:   []int{0, 0, 0, 0},
+		cellFrame: 2,
+		natural:   widths,
+		mins:      widths,
+		weights:   make([]int, n),
+		widths:    make([]int, n),
+		columns:   make([]table.Column, n),
+		header:    make([]string, n),
+		formats:   make([]columnFormat, n),
+		hidden:    map[int]bool{},
+		table:     table.New(),
+	}
+	for i := range n {
+		m.order = append(m.order, i)
+	}
+	return m
+}
+
+func TestLayout(t *testing.T) {
+	for name, tt := range map[string]struct {
+		width     int
+		widths    []int
+		hidden    map[int]bool
+		freeze    int
+		colOffset int
+		editable  bool
+		col       int
+		visible   []int
+		left      bool
+		right     bool
+	}{
+		"all fit":        {width: 32, widths: []int{8, 8}, visible: []int{0, 1}},
+		"clipped":        {width: 32, widths: []int{8, 8, 8, 8, 8}, visible: []int{0, 1, 2}, right: true},
+		"offset":         {width: 32, widths: []int{8, 8, 8, 8, 8}, colOffset: 2, visible: []int{2, 3, 4}, left: true},
+		"offset too far": {width: 32, widths: []int{8, 8, 8, 8, 8}, colOffset: 9, visible: []int{4}, left: true},
+		"frozen":         {width: 32, widths: []int{8, 8, 8, 8, 8}, freeze: 1, colOffset: 3, visible: []int{0, 3, 4}, left: true},
+		"frozen start":   {width: 32, widths: []int{8, 8, 8, 8, 8}, freeze: 1, visible: []int{0, 1, 2}, right: true},
+		"too narrow":     {width: 12, widths: []int{20, 8}, visible: []int{0}, right: true},
+		"hidden column":  {width: 32, widths: []int{8, 8, 8, 8, 8}, hidden: map[int]bool{1: true}, visible: []int{0, 2, 3}, right: true},
+		"active cell":    {width: 32, widths: []int{8, 8, 8, 8, 8}, editable: true, col: 4, visible: []int{2, 3, 4}, left: true},
+		"no columns":     {width: 32},
+		"all hidden":     {width: 32, widths: []int{8, 8}, hidden: map[int]bool{0: true, 1: true}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := layoutModel(tt.width, tt.widths...)
+			if tt.hidden != nil {
+				m.hidden = tt.hidden
+			}
+			m.freeze, m.colOffset = tt.freeze, tt.colOffset
+			m.editable, m.col = tt.editable, tt.col
+			m.layout()
+			if !reflect.DeepEqual(m.visible, tt.visible) {
+				t.Errorf("expected columns %v, got %v", tt.visible, m.visible)
+			}
+			if m.hiddenLeft != tt.left || m.hiddenRight != tt.right {
+				t.Errorf("expected hints %v/%v, got %v/%v", tt.left, tt.right, m.hiddenLeft, m.hiddenRight)
+			}
+		})
+	}
+}
+
+func TestScroll(t *testing.T) {
+	m := layoutModel(32, 8, 8, 8, 8, 8)
+	m.freeze = 1
+	m.layout()
+
+	for _, step := range []struct {
+		delta   int
+		visible []int
+	}{
+		{1, []int{0, 2, 3}},
+		{1, []int{0, 3, 4}},
+		// Nothing is hidden on the right anymore.
+		{1, []int{0, 3, 4}},
+		{-1, []int{0, 2, 3}},
+		{-1, []int{0, 1, 2}},
+		// The frozen column never scrolls out of view.
+		{-1, []int{0, 1, 2}},
+	} {
+		m.scroll(step.delta)
+		if !reflect.DeepEqual(m.visible, step.visible) {
+			t.Fatalf("scrolling by %d: expected columns %v, got %v", step.delta, step.visible, m.visible)
+		}
+	}
+}
+
+func TestScrollHints(t *testing.T) {
+	m := layoutModel(32, 8, 8, 8, 8, 8)
+	m.hiddenLeft, m.hiddenRight = true, false
+	expect := "‹ab \n cd"
+	if got := m.scrollHints("ab\ncd"); got != expect {
+		t.Errorf("expected %q, got %q", expect, got)
+	}
+}
+
+func TestCursorStyle(t *testing.T) {
+	rule := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+	selected := lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	for name, tt := range map[string]struct {
+		row    int
+		cursor int
+		cell   string
+		lines  []string
+	}{
+		"highlighted row":    {row: 0, cursor: 1, cell: "a", lines: []string{rule.Render("a    ")}},
+		"cursor row":         {row: 1, cursor: 1, cell: "b", lines: []string{selected.Render("b    ")}},
+		"highlighted cursor": {row: 0, cursor: 0, cell: "a", lines: []string{selected.Bold(true).Render("a    ")}},
+		"multi-line cursor":  {row: 1, cursor: 1, cell: "b\nc", lines: []string{selected.Render("b    "), selected.Render("c    ")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := layoutModel(0, 5)
+			m.header = []string{"name"}
+			m.layout()
+			styler, err := Options{Highlight: []string{"name=a:fg=196,bold"}}.styler(m.header)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			m.styler, m.selectedStyle = styler, selected
+			m.rows = []table.Row{{"a"}, {"b"}}
+			m.rows[tt.row][0] = tt.cell
+			m.cursor = tt.cursor
+			got := m.renderCell(tt.row, 0, tt.cell, len(tt.lines))
+			if !reflect.DeepEqual(got, tt.lines) {
+				t.Errorf("expected %q, got %q", tt.lines, got)
+			}
+		})
+	}
+}
+
+// detailModel returns a model showing the detail pane of width cells next to
+// the table, with a single row.
+func detailModel(width int, header []string, row table.Row) model {
+	return model{
+		detail:     true,
+		detailPane: viewport.New(viewport.WithWidth(width), viewport.WithHeight(2)),
+		detailRow:  -1,
+		header:     header,
+		formats:    make([]columnFormat, len(header)),
+		rows:       []table.Row{row},
+	}
+}
+
+func TestRefreshDetail(t *testing.T) {
+	for name, tt := range map[string]struct {
+		width   int
+		header  []string
+		row     table.Row
+		cursor  int
+		content string
+	}{
+		"fields":    {width: 20, header: []string{"name", "id"}, row: table.Row{"a", "1"}, content: "name: a\nid:   1"},
+		"wrapped":   {width: 20, header: []string{"name", "note"}, row: table.Row{"a", "hello world again"}, content: "name: a\nnote: hello world\n      again"},
+		"long key":  {width: 20, header: []string{"a very long key", "b"}, row: table.Row{"x", "y"}, content: "a very lo… x\nb:         y"},
+		"no row":    {width: 20, header: []string{"name"}, row: table.Row{"a"}, cursor: -1},
+		"too small": {width: 2, header: []string{"name"}, row: table.Row{"ab"}, content: "… a\n  b"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := detailModel(tt.width, tt.header, tt.row)
+			m.cursor = tt.cursor
+			m.refreshDetail()
+			if got := m.detailPane.GetContent(); got != tt.content {
+				t.Errorf("expected %q, got %q", tt.content, got)
+			}
+		})
+	}
+}
+
+func TestRefreshDetailScroll(t *testing.T) {
+	header := []string{"a", "b", "c", "d", "e", "f"}
+	row := table.Row{"1", "2", "3", "4", "5", "6"}
+	m := detailModel(20, header, row)
+	m.rows = append(m.rows, row)
+	m.refreshDetail()
+
+	m.detailPane.SetYOffset(3)
+	m.refreshDetail()
+	if got := m.detailPane.YOffset(); got != 3 {
+		t.Errorf("expected the pane to keep its offset on the same row, got %d", got)
+	}
+
+	m.cursor = 1
+	m.refreshDetail()
+	if got := m.detailPane.YOffset(); got != 0 {
+		t.Errorf("expected the pane to go back to the top on another row, got %d", got)
+	}
+}
+
+func TestLoadColumnsState(t *testing.T) {
+	header := []string{"a", "b", "c"}
+	for name, tt := range map[string]struct {
+		state  string
+		order  []int
+		hidden []int
+		err    bool
+	}{
+		"no state":     {order: []int{0, 1, 2}},
+		"reordered":    {state: `{"columns":[{"name":"c"},{"name":"a","hidden":true},{"name":"b"}]}`, order: []int{2, 0, 1}, hidden: []int{0}},
+		"unknown":      {state: `{"columns":[{"name":"gone"},{"name":"b","hidden":true}]}`, order: []int{1, 0, 2}, hidden: []int{1}},
+		"duplicate":    {state: `{"columns":[{"name":"b"},{"name":"b","hidden":true}]}`, order: []int{1, 0, 2}},
+		"new columns":  {state: `{"columns":[{"name":"b"}]}`, order: []int{1, 0, 2}},
+		"all hidden":   {state: `{"columns":[{"name":"b","hidden":true},{"name":"a","hidden":true},{"name":"c","hidden":true}]}`, order: []int{1, 0, 2}, hidden: []int{0, 2}},
+		"invalid json": {state: `{"columns":`, err: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "columns.json")
+			if tt.state != "" {
+				if err := os.WriteFile(path, []byte(tt.state), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			order, hidden, err := loadColumnsState(path, header)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(order, tt.order) {
+				t.Errorf("expected order %v, got %v", tt.order, order)
+			}
+			var got []int
+			for _, c := range order {
+				if hidden[c] {
+					got = append(got, c)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.hidden) {
+				t.Errorf("expected hidden columns %v, got %v", tt.hidden, got)
+			}
+		})
+	}
+}
+
+func TestSaveColumnsState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "columns.json")
+	m := model{header: []string{"a", "b", "c"}, order: []int{2, 0, 1}, hidden: map[int]bool{0: true}}
+	if err := m.saveColumnsState(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	order, hidden, err := loadColumnsState(path, m.header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(order, m.order) || !hidden[0] || hidden[1] || hidden[2] {
+		t.Errorf("expected order %v with a hidden, got %v and %v", m.order, order, hidden)
+	}
+}